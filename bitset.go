@@ -0,0 +1,41 @@
+package isso
+
+// bitset is a set of non-negative integers, e.g. the time steps of a requirement's window.
+type bitset []uint64
+
+// newBitset creates a bitset containing the given values.
+func newBitset(values []int) bitset {
+	b := bitset{}
+	for _, v := range values {
+		b.Set(v)
+	}
+	return b
+}
+
+// Set adds a value to the set.
+func (b *bitset) Set(v int) {
+	word := v / 64
+	for len(*b) <= word {
+		*b = append(*b, 0)
+	}
+	(*b)[word] |= 1 << (v % 64)
+}
+
+// Has checks whether the set contains a value.
+func (b bitset) Has(v int) bool {
+	word := v / 64
+	if v < 0 || word >= len(b) {
+		return false
+	}
+	return b[word]&(1<<(v%64)) != 0
+}
+
+// Intersects checks whether two sets have any value in common.
+func (b bitset) Intersects(other bitset) bool {
+	for i := 0; i < len(b) && i < len(other); i++ {
+		if b[i]&other[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package isso
+
+import (
+	"cmp"
+	"slices"
+)
+
+// BranchingStrategy decides the order in which the search branches.
+// See [WithBranching].
+//
+// Select is the variable ordering, i.e. which unsatisfied requirement to branch on next.
+// Order is the value ordering, i.e. in which order the times of the selected requirement are tried.
+// Both only change the order of the search, and must not modify the state.
+//
+// Value ordering never changes the solutions found, except for the order they are found in.
+// Variable ordering decides how the samples of requirements are split between actions and reused,
+// so strategies other than [DefaultBranching] may result in different solutions.
+type BranchingStrategy interface {
+	// Select returns the index of the unsatisfied requirement to branch on, or -1 if all requirements are satisfied.
+	Select(state *SearchState) int
+	// Order sorts the times of the given requirement in place, in the order they are tried.
+	Order(state *SearchState, req int, times []int)
+}
+
+// SearchState gives branching strategies read access to the current state of the search.
+// Requirements are identified by their index in the problem being solved.
+type SearchState struct {
+	coverage *coverage
+	actions  *actions
+}
+
+// Problem returns the problem being solved.
+// With decomposition, this is the current sub-problem.
+func (s *SearchState) Problem() *Problem {
+	return s.coverage.problem
+}
+
+// NumRequirements returns the number of requirements.
+func (s *SearchState) NumRequirements() int {
+	return len(s.coverage.remaining)
+}
+
+// Remaining returns the number of samples still required by the given requirement.
+func (s *SearchState) Remaining(req int) int {
+	return s.coverage.remaining[req]
+}
+
+// Subject returns the subject of the given requirement.
+func (s *SearchState) Subject(req int) SubjectID {
+	return s.coverage.problem.requirements[req].Subject
+}
+
+// Matrix returns the matrix of the given requirement.
+func (s *SearchState) Matrix(req int) MatrixID {
+	return s.coverage.problem.requirements[req].Matrix
+}
+
+// Site returns the site of the given requirement.
+func (s *SearchState) Site(req int) SiteID {
+	return s.coverage.problem.requirements[req].Site
+}
+
+// Times returns the times of the given requirement, in ascending order.
+// The returned slice must not be modified.
+func (s *SearchState) Times(req int) []int {
+	return s.coverage.problem.requirements[req].Times
+}
+
+// Capacity returns the remaining capacity of a site at the given time.
+func (s *SearchState) Capacity(site SiteID, time int) int {
+	return s.coverage.capacity[site][time]
+}
+
+// Visits returns the number of actions taking own samples at a site at the given time,
+// including fixed actions.
+func (s *SearchState) Visits(site SiteID, time int) int {
+	return s.coverage.visits[int(site)*s.coverage.numTimes+time]
+}
+
+// Actions returns the actions taking own samples in the current state, including fixed actions.
+// The returned slice must not be modified.
+func (s *SearchState) Actions() []ActionDef {
+	return s.actions.Actions
+}
+
+// Entries returns the samples covering the given subject in the current state, including reused samples.
+// Returns nil for subjects not in the problem.
+// The returned slice must not be modified.
+func (s *SearchState) Entries(subject SubjectID) []ActionDef {
+	if int(subject) >= len(s.coverage.subjects) || s.coverage.subjects[subject] < 0 {
+		return nil
+	}
+	return s.coverage.entries[s.coverage.subjects[subject]]
+}
+
+// DefaultBranching is the default branching strategy.
+//
+// It selects the first unsatisfied requirement, preferring requirements with more remaining samples
+// for the same matrix, and requirements whose samples can be reused by the selected one for different matrices.
+// Times are tried in ascending order.
+type DefaultBranching struct{}
+
+func (b DefaultBranching) Select(state *SearchState) int {
+	p := state.coverage.problem
+	selected, selectedSamples := -1, 0
+	for r, samples := range state.coverage.remaining {
+		if samples <= 0 {
+			continue
+		}
+		if selected < 0 {
+			selected, selectedSamples = r, samples
+			continue
+		}
+		req, sel := &p.requirements[r], &p.requirements[selected]
+		if req.Matrix == sel.Matrix {
+			// for the same matrix, prefer the larger sample.
+			if samples > selectedSamples {
+				selected, selectedSamples = r, samples
+			}
+		} else if p.reusable[sel.Matrix][req.Matrix] {
+			// if not the same matrix, prefer the one that can be re-used by the other.
+			selected, selectedSamples = r, samples
+		}
+	}
+	return selected
+}
+
+func (b DefaultBranching) Order(state *SearchState, req int, times []int) {}
+
+// MostConstrained selects the unsatisfied requirement with the fewest times with free capacity first,
+// and the one with more remaining samples among equally constrained requirements.
+// Times are tried in ascending order.
+type MostConstrained struct{}
+
+func (b MostConstrained) Select(state *SearchState) int {
+	selected, selectedTimes, selectedSamples := -1, 0, 0
+	for r, samples := range state.coverage.remaining {
+		if samples <= 0 {
+			continue
+		}
+		site := state.Site(r)
+		feasible := 0
+		for _, t := range state.Times(r) {
+			if state.Capacity(site, t) > 0 {
+				feasible++
+			}
+		}
+		if selected < 0 || feasible < selectedTimes || (feasible == selectedTimes && samples > selectedSamples) {
+			selected, selectedTimes, selectedSamples = r, feasible, samples
+		}
+	}
+	return selected
+}
+
+func (b MostConstrained) Order(state *SearchState, req int, times []int) {}
+
+// LargestRemaining selects the unsatisfied requirement with the most remaining samples first.
+// Times are tried in ascending order.
+type LargestRemaining struct{}
+
+func (b LargestRemaining) Select(state *SearchState) int {
+	selected, selectedSamples := -1, 0
+	for r, samples := range state.coverage.remaining {
+		if samples > selectedSamples {
+			selected, selectedSamples = r, samples
+		}
+	}
+	return selected
+}
+
+func (b LargestRemaining) Order(state *SearchState, req int, times []int) {}
+
+// MostCapacity selects requirements like [DefaultBranching],
+// and tries times in the order of descending free capacity.
+type MostCapacity struct {
+	DefaultBranching
+}
+
+func (b MostCapacity) Order(state *SearchState, req int, times []int) {
+	site := state.Site(req)
+	slices.SortStableFunc(times, func(a, b int) int {
+		return cmp.Compare(state.Capacity(site, b), state.Capacity(site, a))
+	})
+}
+
+// VisitedFirst selects requirements like [DefaultBranching],
+// and tries times at which the site is already visited first.
+type VisitedFirst struct {
+	DefaultBranching
+}
+
+func (b VisitedFirst) Order(state *SearchState, req int, times []int) {
+	site := state.Site(req)
+	slices.SortStableFunc(times, func(a, b int) int {
+		return cmp.Compare(min(state.Visits(site, b), 1), min(state.Visits(site, a), 1))
+	})
+}
+
+// CombineBranching creates a branching strategy that selects requirements like the first strategy,
+// and orders times like the second.
+func CombineBranching(requirements, times BranchingStrategy) BranchingStrategy {
+	return combinedBranching{requirements, times}
+}
+
+type combinedBranching struct {
+	requirements BranchingStrategy
+	times        BranchingStrategy
+}
+
+func (b combinedBranching) Select(state *SearchState) int {
+	return b.requirements.Select(state)
+}
+
+func (b combinedBranching) Order(state *SearchState, req int, times []int) {
+	b.times.Order(state, req, times)
+}
@@ -0,0 +1,107 @@
+package isso_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+// reverseTimes is a branching strategy that tries times in descending order.
+type reverseTimes struct {
+	isso.DefaultBranching
+	calls int
+}
+
+func (b *reverseTimes) Order(state *isso.SearchState, req int, times []int) {
+	b.calls++
+	for i, j := 0, len(times)-1; i < j; i, j = i+1, j-1 {
+		times[i], times[j] = times[j], times[i]
+	}
+}
+
+func TestBranchingOrder(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		p := isso.NewProblem(generateProblem(seed, 6, 10, 2))
+
+		for _, pareto := range []bool{false, true} {
+			var comp isso.Comparator[fitness.TripsAndSamplesFitness] = &fitness.TripsThenSamples{}
+			if pareto {
+				comp = &fitness.TripsSamplesPareto{}
+			}
+			s := isso.NewSolver(&fitness.TripsAndSamplesEvaluator{}, comp)
+			expected, ok := s.Solve(&p)
+
+			custom := reverseTimes{}
+			strategies := []isso.BranchingStrategy{
+				isso.MostCapacity{},
+				isso.VisitedFirst{},
+				isso.CombineBranching(isso.DefaultBranching{}, isso.MostCapacity{}),
+				&custom,
+			}
+			for i, strategy := range strategies {
+				msg := fmt.Sprintf("seed %d, pareto %v, strategy %d", seed, pareto, i)
+				s := isso.NewSolver(&fitness.TripsAndSamplesEvaluator{}, comp, isso.WithBranching(strategy))
+				solutions, okStrategy := s.Solve(&p)
+				assert.Equal(t, ok, okStrategy, msg)
+				assert.Equal(t, fitnessSet(expected), fitnessSet(solutions), msg)
+			}
+			assert.Greater(t, custom.calls, 0)
+		}
+	}
+}
+
+func TestBranchingSelect(t *testing.T) {
+	strategies := []isso.BranchingStrategy{
+		isso.MostConstrained{},
+		isso.LargestRemaining{},
+		isso.CombineBranching(isso.MostConstrained{}, isso.VisitedFirst{}),
+	}
+	for seed := int64(0); seed < 10; seed++ {
+		def := generateProblem(seed, 6, 10, 2)
+		p := isso.NewProblem(def)
+
+		for i, strategy := range strategies {
+			msg := fmt.Sprintf("seed %d, strategy %d", seed, i)
+			s := isso.NewSolver(&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{}, isso.WithBranching(strategy))
+			solutions, ok := s.Solve(&p)
+			if !ok {
+				continue
+			}
+			for _, sol := range solutions {
+				samples := map[string]int{}
+				for _, a := range sol.Actions {
+					samples[a.Subject] += a.Samples
+				}
+				for _, r := range def.Requirements {
+					assert.Equal(t, r.Samples, samples[r.Subject], msg)
+				}
+			}
+		}
+	}
+}
+
+func TestMostConstrained(t *testing.T) {
+	p := isso.NewProblem(isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}, {Name: "shoots"}},
+		Capacity: []int{100, 100, 100, 0},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Matrix: "fruits", Samples: 50, Times: []int{0, 1, 2}},
+			{Subject: "Pest 2", Matrix: "shoots", Samples: 20, Times: []int{2, 3}},
+		},
+	})
+
+	s := isso.NewSolver[fitness.TripsAndSamplesFitness](
+		&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{},
+		isso.WithBranching(isso.MostConstrained{}),
+	)
+	solutions, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, fitness.TripsAndSamplesFitness{Trips: 1, Samples: 70}, solutions[0].Fitness)
+	assert.Equal(t, 1, len(solutions))
+	assert.Equal(t, 2, solutions[0].Actions[0].Time)
+	// Pest 2 is branched on first, as it has only one time with free capacity.
+	assert.Equal(t, 5, s.Stats().Nodes)
+}
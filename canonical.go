@@ -0,0 +1,165 @@
+package isso
+
+import (
+	"cmp"
+	"hash/fnv"
+	"slices"
+	"strconv"
+)
+
+// canonicalActions returns the canonical form of a solution's actions. See [Solution.Canonical].
+// Actions are sorted by IDs rather than names, which is sufficient for comparing canonical forms.
+func canonicalActions(p *Problem, actions []ActionDef) []ActionDef {
+	result := make([]ActionDef, 0, len(actions))
+	for _, a := range actions {
+		if a.Reuse >= 0 {
+			a.Reuse = canonicalSource(p, actions, &a)
+		}
+		idx := slices.IndexFunc(result, func(b ActionDef) bool {
+			return b.Subject == a.Subject && b.Reuse == a.Reuse && b.Site == a.Site && b.Time == a.Time
+		})
+		if idx >= 0 {
+			result[idx].Samples += a.Samples
+			continue
+		}
+		result = append(result, a)
+	}
+	slices.SortFunc(result, func(a, b ActionDef) int {
+		if c := cmp.Compare(a.Time, b.Time); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Site, b.Site); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Subject, b.Subject); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Reuse, b.Reuse)
+	})
+	return result
+}
+
+// canonicalSource returns the first subject by name with own samples of the same matrix as the reused samples,
+// at the same site and time.
+func canonicalSource(p *Problem, actions []ActionDef, reuse *ActionDef) SubjectID {
+	matrix := MatrixID(-1)
+	for i := range actions {
+		a := &actions[i]
+		if a.Reuse < 0 && a.Subject == reuse.Reuse && a.Site == reuse.Site && a.Time == reuse.Time {
+			matrix = a.Matrix
+			break
+		}
+	}
+	source := reuse.Reuse
+	for i := range actions {
+		a := &actions[i]
+		if a.Reuse < 0 && a.Matrix == matrix && a.Site == reuse.Site && a.Time == reuse.Time &&
+			p.SubjectName(a.Subject) < p.SubjectName(source) {
+			source = a.Subject
+		}
+	}
+	return source
+}
+
+// hashActions hashes canonical actions.
+func hashActions(actions []ActionDef) uint64 {
+	h := uint64(len(actions))
+	for i := range actions {
+		a := &actions[i]
+		h = mix(h ^ uint64(a.Subject))
+		h = mix(h ^ uint64(a.Reuse))
+		h = mix(h ^ uint64(a.Site))
+		h = mix(h ^ uint64(a.Time))
+		h = mix(h ^ uint64(a.Samples))
+	}
+	return h
+}
+
+// Canonical returns the canonical form of the solution, for comparing schedules.
+//
+// In the canonical form, reuse is attributed to the first subject by name with own samples of the same matrix
+// as the reused samples, at the same site and time. Actions of the same subject, site, time, reuse and lab are merged,
+// and actions are sorted by time, site, subject, reuse and lab.
+// Two solutions that differ only in the order of actions or in the attribution of reuse
+// between equivalent subjects have the same canonical form.
+func (s *Solution[F]) Canonical() Solution[F] {
+	actions := make([]Action, 0, len(s.Actions))
+	for _, a := range s.Actions {
+		if a.Reuse != "" {
+			a.Reuse = canonicalSourceName(s.Actions, &a)
+		}
+		idx := slices.IndexFunc(actions, func(b Action) bool {
+			return b.Subject == a.Subject && b.Reuse == a.Reuse && b.Site == a.Site && b.Time == a.Time && b.Lab == a.Lab
+		})
+		if idx >= 0 {
+			actions[idx].Samples += a.Samples
+			continue
+		}
+		actions = append(actions, a)
+	}
+	slices.SortFunc(actions, func(a, b Action) int {
+		if c := cmp.Compare(a.Time, b.Time); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Site, b.Site); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Subject, b.Subject); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Reuse, b.Reuse); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Lab, b.Lab)
+	})
+
+	canonical := *s
+	canonical.Actions = actions
+	return canonical
+}
+
+// canonicalSourceName is like [canonicalSource], but for actions using names.
+func canonicalSourceName(actions []Action, reuse *Action) string {
+	matrix, found := "", false
+	for i := range actions {
+		a := &actions[i]
+		if a.Reuse == "" && a.Subject == reuse.Reuse && a.Site == reuse.Site && a.Time == reuse.Time {
+			matrix, found = a.Matrix, true
+			break
+		}
+	}
+	source := reuse.Reuse
+	if !found {
+		return source
+	}
+	for i := range actions {
+		a := &actions[i]
+		if a.Reuse == "" && a.Matrix == matrix && a.Site == reuse.Site && a.Time == reuse.Time && a.Subject < source {
+			source = a.Subject
+		}
+	}
+	return source
+}
+
+// Equal checks whether two solutions have the same schedule, i.e. the same canonical actions.
+// Fitness and derived information like tours and submissions are not compared.
+func (s *Solution[F]) Equal(other *Solution[F]) bool {
+	a, b := s.Canonical(), other.Canonical()
+	return slices.Equal(a.Actions, b.Actions)
+}
+
+// Hash returns a hash of the solution's schedule, consistent with [Solution.Equal].
+func (s *Solution[F]) Hash() uint64 {
+	h := fnv.New64a()
+	for _, a := range s.Canonical().Actions {
+		for _, field := range []string{a.Subject, a.Matrix, a.Reuse, a.Site, a.Label, a.Lab} {
+			h.Write([]byte(field))
+			h.Write([]byte{0})
+		}
+		for _, value := range []int{a.Time, a.Samples, a.TargetSamples} {
+			h.Write(strconv.AppendInt(nil, int64(value), 10))
+			h.Write([]byte{0})
+		}
+	}
+	return h.Sum64()
+}
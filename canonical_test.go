@@ -0,0 +1,77 @@
+package isso_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolutionCanonical(t *testing.T) {
+	type sol = isso.Solution[fitness.TripsAndSamplesFitness]
+
+	a := sol{Actions: []isso.Action{
+		{Subject: "Pest 3", Matrix: "fruits", Time: 1, Samples: 50},
+		{Subject: "Pest 1", Matrix: "fruits", Time: 1, Samples: 100},
+		{Subject: "Pest 2", Matrix: "fruits", Time: 1, Samples: 30, Reuse: "Pest 3"},
+		{Subject: "Pest 2", Matrix: "fruits", Time: 1, Samples: 20, Reuse: "Pest 1"},
+		{Subject: "Pest 4", Matrix: "shoots", Time: 0, Samples: 10},
+	}}
+	b := sol{Actions: []isso.Action{
+		{Subject: "Pest 4", Matrix: "shoots", Time: 0, Samples: 10},
+		{Subject: "Pest 1", Matrix: "fruits", Time: 1, Samples: 100},
+		{Subject: "Pest 2", Matrix: "fruits", Time: 1, Samples: 50, Reuse: "Pest 1"},
+		{Subject: "Pest 3", Matrix: "fruits", Time: 1, Samples: 50},
+	}}
+
+	assert.Equal(t, []isso.Action{
+		{Subject: "Pest 4", Matrix: "shoots", Time: 0, Samples: 10},
+		{Subject: "Pest 1", Matrix: "fruits", Time: 1, Samples: 100},
+		{Subject: "Pest 2", Matrix: "fruits", Time: 1, Samples: 50, Reuse: "Pest 1"},
+		{Subject: "Pest 3", Matrix: "fruits", Time: 1, Samples: 50},
+	}, a.Canonical().Actions)
+
+	assert.True(t, a.Equal(&b))
+	assert.True(t, b.Equal(&a))
+	assert.Equal(t, a.Hash(), b.Hash())
+	assert.Equal(t, 5, len(a.Actions))
+
+	c := b.Canonical()
+	c.Actions[3].Samples = 40
+	assert.False(t, a.Equal(&c))
+	assert.NotEqual(t, a.Hash(), c.Hash())
+
+	// Reuse from a different matrix is not equivalent.
+	d := b.Canonical()
+	d.Actions[1].Matrix = "fruits & shoots"
+	assert.False(t, a.Equal(&d))
+}
+
+func TestSolveDistinct(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		def := generateProblem(seed, 6, 10, 2)
+		if seed >= 10 {
+			// Subject names in reverse order of subject IDs.
+			for i := range def.Requirements {
+				def.Requirements[i].Subject = fmt.Sprintf("Pest %d", len(def.Requirements)-i)
+			}
+		}
+		p := isso.NewProblem(def)
+
+		for _, opt := range []isso.Option{isso.WithTopK(20), isso.WithTolerance(0.1), isso.WithBranching(isso.DefaultBranching{})} {
+			s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{}, opt)
+			solutions, _ := s.Solve(&p)
+
+			hashes := map[uint64]bool{}
+			for i := range solutions {
+				for j := i + 1; j < len(solutions); j++ {
+					assert.False(t, solutions[i].Equal(&solutions[j]), fmt.Sprintf("seed %d, solutions %d and %d", seed, i, j))
+				}
+				hashes[solutions[i].Hash()] = true
+			}
+			assert.Equal(t, len(solutions), len(hashes))
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mlange-42/isso"
+	"github.com/spf13/cobra"
+)
+
+func checkCommand() *cobra.Command {
+	var input inputOptions
+	var file string
+
+	check := &cobra.Command{
+		Use:   "check",
+		Short: "Verify solutions against a problem definition",
+		Long: `Verify solutions against a problem definition.
+
+Takes a solution JSON file, as written with '--format json' or by other tools,
+and checks all solutions for violations of capacity, requirement times and samples,
+reuse of samples, tour durations and constraints.
+Solutions are checked against the problem in the solution file, unless a problem is given with --input or CSV tables.
+Exits with an error if any violations are found.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := runCheck(&input, file)
+			fmt.Print(out)
+			return err
+		},
+	}
+
+	input.addFlags(check)
+	check.Flags().StringVarP(&input.csvDelimiter, "delim", "d", ",", "Column delimiter for CSV input")
+	check.Flags().StringVarP(&file, "solution", "s", "", "Solution JSON file, as written with '--format json'")
+	_ = check.MarkFlagRequired("solution")
+
+	return check
+}
+
+func runCheck(input *inputOptions, file string) (string, error) {
+	solutions, err := readSolutions(file)
+	if err != nil {
+		return "", err
+	}
+	problem := solutions.Problem
+	if input.given() {
+		problem, _, err = readProblem(input)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	p := isso.NewProblem(problem)
+	b := strings.Builder{}
+	total, invalid := 0, 0
+	for i, sol := range solutions.Solutions {
+		violations := p.Verify(sol.Actions)
+		if len(violations) == 0 {
+			b.WriteString(fmt.Sprintf("Solution %d: OK\n", i))
+			continue
+		}
+		total += len(violations)
+		invalid++
+		b.WriteString(fmt.Sprintf("Solution %d: %d violation(s)\n", i, len(violations)))
+		for _, v := range violations {
+			b.WriteString(fmt.Sprintf("  %s\n", v.String()))
+		}
+	}
+
+	if total > 0 {
+		return b.String(), fmt.Errorf("found %d violation(s) in %d of %d solution(s)", total, invalid, len(solutions.Solutions))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/mlange-42/isso"
+	"github.com/spf13/cobra"
+)
+
+func convertCommand() *cobra.Command {
+	var input string
+	var output string
+	var to string
+
+	convert := &cobra.Command{
+		Use:   "convert",
+		Short: "Convert a problem definition between JSON, YAML and TOML",
+		Long: `Convert a problem definition between JSON, YAML and TOML.
+
+Formats are detected from file extensions. The problem is validated before writing.
+Times given as dates, labels or ranges are preserved.
+Keys are sorted alphabetically, and comments are not preserved.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := runConvert(input, output, to)
+			if err != nil {
+				return err
+			}
+
+			fmt.Print(out)
+
+			return nil
+		},
+	}
+
+	convert.Flags().StringVarP(&input, "input", "i", "", "Input problem file")
+	convert.Flags().StringVarP(&output, "output", "o", "", "Output problem file. Prints to stdout if not given")
+	convert.Flags().StringVarP(&to, "to", "t", "", "Output format. One of [json yaml toml]. Defaults to the format of --output")
+	_ = convert.MarkFlagRequired("input")
+
+	return convert
+}
+
+func runConvert(input, output, to string) (string, error) {
+	var format isso.Format
+	var err error
+	if to != "" {
+		format, err = isso.ParseFormat(to)
+		if err != nil {
+			return "", err
+		}
+	} else if output != "" {
+		format = isso.FormatOf(output)
+	} else {
+		return "", fmt.Errorf("output format required; use --to or --output")
+	}
+
+	f, err := os.Open(input)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	b := bytes.Buffer{}
+	if err := isso.ConvertProblem(f, isso.FormatOf(input), &b, format); err != nil {
+		return "", fmt.Errorf("%s: %s", input, err.Error())
+	}
+
+	if output == "" {
+		return b.String(), nil
+	}
+	return "", os.WriteFile(output, b.Bytes(), 0644)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/phenology"
+	"github.com/spf13/cobra"
+)
+
+func describeCommand() *cobra.Command {
+	var input inputOptions
+
+	describe := &cobra.Command{
+		Use:   "describe",
+		Short: "Print a report of a problem definition",
+		Long: `Print a report of a problem definition.
+
+With --phenology and --temperatures, the report includes the requirement windows
+derived from temperature data.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := runDescribe(&input)
+			if err != nil {
+				return err
+			}
+
+			fmt.Print(out)
+
+			return nil
+		},
+	}
+
+	input.addFlags(describe)
+	describe.Flags().StringVarP(&input.csvDelimiter, "delim", "d", ",", "Column delimiter for CSV input")
+
+	return describe
+}
+
+func runDescribe(input *inputOptions) (string, error) {
+	problem, derived, err := readProblem(input)
+	if err != nil {
+		return "", err
+	}
+	return describe(&problem, derived), nil
+}
+
+// describe creates a report of a problem definition.
+func describe(problem *isso.ProblemDef, derived []phenology.Derived) string {
+	b := strings.Builder{}
+	tl := problem.Timeline
+
+	steps := problem.NumTimes()
+
+	b.WriteString(fmt.Sprintf("Time steps: %d\n", steps))
+	if tl != nil {
+		if len(tl.Labels) > 0 {
+			b.WriteString(fmt.Sprintf("Timeline:   %s .. %s\n", tl.Label(0), tl.Label(steps-1)))
+		} else {
+			b.WriteString(fmt.Sprintf("Timeline:   %s .. %s, step %s\n", tl.Label(0), tl.Label(steps-1), tl.Step))
+		}
+	}
+	if problem.CurrentTime > 0 {
+		b.WriteString(fmt.Sprintf("Current:    %s\n", tl.Label(problem.CurrentTime)))
+	}
+
+	b.WriteString("\nMatrices\n")
+	for _, m := range problem.Matrices {
+		if len(m.CanReuse) == 0 {
+			b.WriteString(fmt.Sprintf("  %s\n", m.Name))
+		} else {
+			b.WriteString(fmt.Sprintf("  %-18s reuses %s\n", m.Name, strings.Join(m.CanReuse, ", ")))
+		}
+	}
+
+	b.WriteString("\nCapacity\n")
+	if len(problem.Sites) == 0 {
+		for i, c := range problem.Capacity {
+			b.WriteString(fmt.Sprintf("  %12s %6d\n", tl.Label(i), c))
+		}
+	} else {
+		b.WriteString(fmt.Sprintf("  %12s", ""))
+		for _, site := range problem.Sites {
+			b.WriteString(fmt.Sprintf(" %12s", site.Name))
+		}
+		b.WriteString("\n")
+		for i := 0; i < steps; i++ {
+			b.WriteString(fmt.Sprintf("  %12s", tl.Label(i)))
+			for _, site := range problem.Sites {
+				if i < len(site.Capacity) {
+					b.WriteString(fmt.Sprintf(" %12d", site.Capacity[i]))
+				} else {
+					b.WriteString(fmt.Sprintf(" %12s", "-"))
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("\nRequirements\n")
+	b.WriteString(fmt.Sprintf("  %-10s %12s %18s %8s  %s\n", "Subject", "Site", "Matrix", "Samples", "Times"))
+	for _, r := range problem.Requirements {
+		b.WriteString(fmt.Sprintf("  %-10s %12s %18s %8d  %s\n", r.Subject, r.Site, r.Matrix, r.Samples, tl.Format(r.Times)))
+	}
+
+	if len(problem.Labs) > 0 {
+		b.WriteString("\nLabs\n")
+		b.WriteString(fmt.Sprintf("  %-12s %8s %10s  %s\n", "Lab", "Cost", "Turnaround", "Subjects"))
+		for _, lab := range problem.Labs {
+			b.WriteString(fmt.Sprintf("  %-12s %8.2f %10d  %s\n", lab.Name, lab.Cost, lab.Turnaround, strings.Join(lab.Subjects, ", ")))
+		}
+	}
+
+	if len(problem.Constraints) > 0 {
+		b.WriteString("\nConstraints\n")
+		for _, c := range problem.Constraints {
+			b.WriteString(fmt.Sprintf("  %-16s %s\n", c.Type, c.Describe(tl)))
+		}
+	}
+
+	if len(derived) > 0 {
+		b.WriteString("\nPhenology windows\n")
+		b.WriteString(fmt.Sprintf("  %-10s %18s %24s  %s\n", "Subject", "Degree-days", "Days", "Times"))
+		for _, d := range derived {
+			b.WriteString(fmt.Sprintf("  %-10s %7.1f .. %7.1f %s .. %s  %s\n",
+				d.Subject, d.From, d.To,
+				d.FirstDay.Format(isso.DateFormat), d.LastDay.Format(isso.DateFormat),
+				tl.Format(d.Times)))
+		}
+	}
+
+	return b.String()
+}
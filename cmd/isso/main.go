@@ -3,11 +3,14 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/mlange-42/isso"
 	"github.com/mlange-42/isso/fitness"
+	"github.com/mlange-42/isso/phenology"
 	"github.com/spf13/cobra"
 )
 
@@ -18,12 +21,90 @@ func main() {
 	}
 }
 
+// inputOptions define how to read a problem.
+type inputOptions struct {
+	file         string
+	requirements string
+	capacity     string
+	matrices     string
+	phenology    string
+	temperatures string
+	csvDelimiter string
+}
+
+func (o *inputOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.file, "input", "i", "", "Input problem file, as JSON, YAML or TOML by file extension")
+	cmd.Flags().StringVar(&o.requirements, "requirements", "", "Requirements CSV file. Alternative to --input, or replaces its requirements")
+	cmd.Flags().StringVar(&o.capacity, "capacity", "", "Capacity CSV file. Alternative to --input, or replaces its capacity and sites")
+	cmd.Flags().StringVar(&o.matrices, "matrices", "", "Matrices CSV file. Alternative to --input, or replaces its matrices")
+	cmd.Flags().StringVar(&o.phenology, "phenology", "", "Phenology JSON file for deriving requirement times from temperatures")
+	cmd.Flags().StringVar(&o.temperatures, "temperatures", "", "Daily temperatures CSV file, required with --phenology")
+}
+
+// given checks whether any problem input is given.
+func (o *inputOptions) given() bool {
+	return o.file != "" || o.requirements != "" || o.capacity != "" || o.matrices != ""
+}
+
+// delimiter returns the column delimiter for CSV input.
+func (o *inputOptions) delimiter() rune {
+	if o.csvDelimiter == "" {
+		return ','
+	}
+	return []rune(o.csvDelimiter)[0]
+}
+
+// outputOptions define how to solve a problem and format the results.
+type outputOptions struct {
+	format       string
+	csvDelimiter string
+	pareto       bool
+	combineSites bool
+	fitness      string
+	tableMB      int
+	decompose    bool
+	branching    string
+	anytime      bool
+	top          int
+	tolerance    float64
+	stats        bool
+}
+
+func (o *outputOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.format, "format", "f", "table", "Output format. One of [json table csv list fitness manifest]")
+	cmd.Flags().StringVarP(&o.csvDelimiter, "delim", "d", ",", "Column delimiter for CSV input and output")
+	cmd.Flags().BoolVarP(&o.pareto, "pareto", "p", false, "Use pareto optimization criterion")
+	cmd.Flags().StringVar(&o.fitness, "fitness", "trips", "Fitness function. One of [trips route cost]")
+	cmd.Flags().BoolVar(&o.combineSites, "combine-sites", false, "Count sites visited at the same time as a single trip")
+	cmd.Flags().IntVar(&o.tableMB, "table", 0, "Memory limit of the transposition table in MB. 0 to disable")
+	cmd.Flags().BoolVar(&o.decompose, "decompose", false, "Solve independent sub-problems separately")
+	cmd.Flags().StringVar(&o.branching, "branching", "default",
+		"Branching strategy. One of [default constrained largest capacity visited], "+
+			"or a strategy for requirements and one for times, separated by a comma")
+	cmd.Flags().IntVar(&o.top, "top", 0, "Number of best solutions to keep, instead of only the best ones. 0 to disable")
+	cmd.Flags().Float64Var(&o.tolerance, "tolerance", 0, "Keep solutions within this relative tolerance of the best, e.g. 0.1 for 10%. 0 to disable")
+	cmd.Flags().BoolVar(&o.anytime, "anytime", false, "Start with a greedy solution, and print improved solutions to stderr while solving")
+	cmd.Flags().BoolVar(&o.stats, "stats", false, "Print solver statistics to stderr")
+}
+
+// settings returns the solver settings for solution files.
+func (o *outputOptions) settings() isso.SolverSettings {
+	return isso.SolverSettings{
+		Fitness:      o.fitness,
+		Pareto:       o.pareto,
+		CombineSites: o.combineSites,
+		Branching:    o.branching,
+		TableBytes:   o.tableMB * 1024 * 1024,
+		Decompose:    o.decompose,
+		TopK:         o.top,
+		Tolerance:    o.tolerance,
+	}
+}
+
 // RootCommand sets up the CLI
 func RootCommand() *cobra.Command {
-	var format string
-	var file string
-	var csvDelimiter string
-	var pareto bool
+	var input inputOptions
+	var output outputOptions
 
 	root := &cobra.Command{
 		Use:           "isso",
@@ -35,55 +116,260 @@ func RootCommand() *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if file == "" {
+			if !input.given() {
 				_ = cmd.Help()
 				return nil
 			}
 
-			output, err := run(file, format, csvDelimiter, pareto)
+			input.csvDelimiter = output.csvDelimiter
+			out, err := run(&input, &output)
 			if err != nil {
 				return err
 			}
 
-			fmt.Print(output)
+			fmt.Print(out)
 
 			return nil
 		},
 	}
 
-	root.Flags().StringVarP(&file, "input", "i", "", "Input JSON file")
-	root.Flags().StringVarP(&format, "format", "f", "table", "Output format. One of [json table csv list fitness]")
-	root.Flags().StringVarP(&csvDelimiter, "delim", "d", ",", "Column delimiter for CSV output")
-	root.Flags().BoolVarP(&pareto, "pareto", "p", false, "Use pareto optimization criterion")
+	input.addFlags(root)
+	output.addFlags(root)
+
+	root.AddCommand(replanCommand())
+	root.AddCommand(describeCommand())
+	root.AddCommand(checkCommand())
+	root.AddCommand(convertCommand())
 
 	return root
 }
 
-func run(file, format string, csvDelimiter string, pareto bool) (string, error) {
-	jsData, err := os.ReadFile(file)
+func run(input *inputOptions, output *outputOptions) (string, error) {
+	problem, _, err := readProblem(input)
 	if err != nil {
 		return "", err
 	}
+
+	return solve(problem, output)
+}
+
+// readProblem reads a problem definition, and derives requirement times from phenology if requested.
+// CSV tables replace the respective parts of the problem file, or make up the problem without it.
+func readProblem(input *inputOptions) (isso.ProblemDef, []phenology.Derived, error) {
 	problem := isso.ProblemDef{}
-	err = json.Unmarshal(jsData, &problem)
+	if input.file != "" {
+		file, err := os.Open(input.file)
+		if err != nil {
+			return problem, nil, err
+		}
+		defer file.Close()
+
+		problem, err = isso.ReadProblem(file, isso.FormatOf(input.file))
+		if err != nil {
+			return problem, nil, fmt.Errorf("%s: %s", input.file, err.Error())
+		}
+	} else if input.requirements == "" || input.capacity == "" || input.matrices == "" {
+		return problem, nil, fmt.Errorf("problem requires --input, or --requirements, --capacity and --matrices")
+	}
+
+	// Capacity first, as it can define the timeline for requirement times.
+	tables := []struct {
+		file string
+		read func(io.Reader, rune) error
+	}{
+		{input.capacity, problem.ReadCapacity},
+		{input.matrices, problem.ReadMatrices},
+		{input.requirements, problem.ReadRequirements},
+	}
+	for _, t := range tables {
+		if t.file == "" {
+			continue
+		}
+		if err := readTable(t.file, input.delimiter(), t.read); err != nil {
+			return problem, nil, err
+		}
+	}
+
+	if input.phenology == "" {
+		return problem, nil, nil
+	}
+	if input.temperatures == "" {
+		return problem, nil, fmt.Errorf("phenology requires a temperatures file")
+	}
+
+	jsData, err := os.ReadFile(input.phenology)
 	if err != nil {
-		return "", err
+		return problem, nil, err
+	}
+	config := phenology.Config{}
+	err = json.Unmarshal(jsData, &config)
+	if err != nil {
+		return problem, nil, err
+	}
+
+	f, err := os.Open(input.temperatures)
+	if err != nil {
+		return problem, nil, err
+	}
+	defer f.Close()
+
+	temps, err := phenology.ReadTemperatures(f, input.delimiter())
+	if err != nil {
+		return problem, nil, err
+	}
+
+	derived, err := config.Apply(&problem, temps)
+	if err != nil {
+		return problem, nil, err
+	}
+	return problem, derived, nil
+}
+
+// readTable reads a CSV table of a problem definition.
+func readTable(file string, delim rune, read func(io.Reader, rune) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := read(f, delim); err != nil {
+		return fmt.Errorf("%s: %s", file, err.Error())
 	}
+	return nil
+}
 
+// solve solves the given problem and formats the solutions.
+func solve(problem isso.ProblemDef, output *outputOptions) (string, error) {
 	p := isso.NewProblem(problem)
 
-	var comp isso.Comparator[fitness.TripsAndSamplesFitness]
-	if pareto {
-		comp = &fitness.TripsSamplesPareto{}
-	} else {
-		comp = &fitness.TripsThenSamples{}
+	switch output.fitness {
+	case "", "trips":
+		var comp isso.Comparator[fitness.TripsAndSamplesFitness]
+		if output.pareto {
+			comp = &fitness.TripsSamplesPareto{}
+		} else {
+			comp = &fitness.TripsThenSamples{}
+		}
+		return solveWith(&p, &problem, &fitness.TripsAndSamplesEvaluator{CombineSites: output.combineSites}, comp, output)
+
+	case "route":
+		if output.pareto {
+			return "", fmt.Errorf("pareto optimization is not supported for fitness 'route'")
+		}
+		if problem.Travel == nil {
+			return "", fmt.Errorf("fitness 'route' requires a problem with travel durations")
+		}
+		return solveWith(&p, &problem, &fitness.RouteEvaluator{}, &fitness.RouteThenSamples{}, output)
+
+	case "cost":
+		if output.pareto {
+			return "", fmt.Errorf("pareto optimization is not supported for fitness 'cost'")
+		}
+		if problem.Costs == nil {
+			return "", fmt.Errorf("fitness 'cost' requires a problem with costs")
+		}
+		return solveWith(&p, &problem, &fitness.CostEvaluator{CombineSites: output.combineSites}, &fitness.LowestCost{}, output)
+
+	default:
+		return "", fmt.Errorf("unknown fitness '%s'", output.fitness)
+	}
+}
+
+// solveAnytime solves the given problem in anytime mode, and prints improved solutions to stderr as they are found.
+func solveAnytime[F fitnessValue](s *isso.Solver[F], p *isso.Problem) ([]isso.Solution[F], bool) {
+	start := time.Now()
+	improvements := make(chan isso.Solution[F])
+	done := make(chan struct{})
+	go func() {
+		for sol := range improvements {
+			fmt.Fprintf(os.Stderr, "[%7.3fs] %s\n", time.Since(start).Seconds(), sol.Fitness.String())
+		}
+		close(done)
+	}()
+	solution, ok := s.SolveAnytime(p, improvements)
+	<-done
+	return solution, ok
+}
+
+// branchingStrategies are the branching strategies available in the CLI, by name.
+var branchingStrategies = map[string]isso.BranchingStrategy{
+	"default":     isso.DefaultBranching{},
+	"constrained": isso.MostConstrained{},
+	"largest":     isso.LargestRemaining{},
+	"capacity":    isso.MostCapacity{},
+	"visited":     isso.VisitedFirst{},
+}
+
+// parseBranching parses a branching strategy name, or a pair of names for requirements and times.
+func parseBranching(name string) (isso.BranchingStrategy, error) {
+	if name == "" {
+		return isso.DefaultBranching{}, nil
+	}
+	names := strings.Split(name, ",")
+	if len(names) > 2 {
+		return nil, fmt.Errorf("invalid branching strategy '%s'; expected at most two names", name)
+	}
+	strategies := make([]isso.BranchingStrategy, len(names))
+	for i, n := range names {
+		strategy, ok := branchingStrategies[strings.TrimSpace(n)]
+		if !ok {
+			return nil, fmt.Errorf("unknown branching strategy '%s'", n)
+		}
+		strategies[i] = strategy
+	}
+	if len(strategies) == 1 {
+		return strategies[0], nil
+	}
+	return isso.CombineBranching(strategies[0], strategies[1]), nil
+}
+
+// fitnessValue is the type constraint for fitness values that can be solved and printed.
+type fitnessValue interface {
+	fmt.Stringer
+}
+
+// solveWith solves the given problem with the given fitness function, and formats the solutions.
+func solveWith[F fitnessValue](p *isso.Problem, problem *isso.ProblemDef,
+	evaluator isso.Evaluator[F], comparator isso.Comparator[F], output *outputOptions) (string, error) {
+
+	branching, err := parseBranching(output.branching)
+	if err != nil {
+		return "", err
+	}
+	options := []isso.Option{isso.WithBranching(branching)}
+	if output.top > 0 || output.tolerance > 0 {
+		if output.pareto {
+			return "", fmt.Errorf("options --top and --tolerance are not supported for pareto optimization")
+		}
+		options = append(options, isso.WithTopK(output.top), isso.WithTolerance(output.tolerance))
+	}
+	if output.tableMB > 0 {
+		options = append(options, isso.WithTranspositionTable(output.tableMB*1024*1024))
+	}
+	if output.decompose {
+		options = append(options, isso.WithDecomposition())
 	}
 
-	s := isso.NewSolver(
-		&fitness.TripsAndSamplesEvaluator{},
-		comp,
-	)
-	solution, ok := s.Solve(&p)
+	s := isso.NewSolver(evaluator, comparator, options...)
+	var solution []isso.Solution[F]
+	var ok bool
+	if output.anytime {
+		solution, ok = solveAnytime(&s, p)
+	} else {
+		solution, ok = s.Solve(p)
+	}
+	if output.stats {
+		stats := s.Stats()
+		fmt.Fprintf(os.Stderr, "Nodes: %d\n", stats.Nodes)
+		if stats.TableSize > 0 {
+			fmt.Fprintf(os.Stderr, "Table: %d entries, %d hits in %d probes (%.1f%%)\n",
+				stats.TableSize, stats.Hits, stats.Probes, 100*stats.HitRate())
+		}
+		if len(stats.Components) > 0 {
+			fmt.Fprintf(os.Stderr, "Components: %v\n", stats.Components)
+		}
+	}
 	if !ok {
 		fmt.Println("No solution found")
 		return "", nil
@@ -92,43 +378,62 @@ func run(file, format string, csvDelimiter string, pareto bool) (string, error)
 	fmt.Fprintf(os.Stderr, "Found %d solution(s)\n\n", len(solution))
 
 	b := strings.Builder{}
-	switch format {
+	switch output.format {
 	case "json":
-		enc := json.NewEncoder(&b)
-		enc.SetEscapeHTML(false)
-		enc.SetIndent("", "    ")
-		err = enc.Encode(&solution)
+		err := isso.WriteSolutions(&b, &isso.SolutionFile[F]{
+			Problem:   *problem,
+			Settings:  output.settings(),
+			Stats:     s.Stats(),
+			Solutions: solution,
+		})
 		if err != nil {
 			return "", err
 		}
-		b.WriteString(fmt.Sprintln(string(jsData)))
 
 	case "table":
 		for _, sol := range solution {
 			b.WriteString(fmt.Sprintln(sol.ToTable()))
-			b.WriteString(fmt.Sprintf("(%d trips, %d samples)\n", sol.Fitness.Trips, sol.Fitness.Samples))
+			if len(sol.Tours) > 0 {
+				b.WriteString(fmt.Sprintln("Tours"))
+				b.WriteString(fmt.Sprintln(sol.ToTours()))
+			}
+			b.WriteString(fmt.Sprintf("(%v)\n", sol.Fitness))
 			b.WriteString(fmt.Sprintln("------------------------------------------------------------"))
 		}
 
 	case "csv":
 		for i, sol := range solution {
-			b.WriteString(fmt.Sprint(sol.ToCSV(i, csvDelimiter)))
+			b.WriteString(fmt.Sprint(sol.ToCSV(i, output.csvDelimiter)))
 		}
 
 	case "list":
 		for _, sol := range solution {
 			b.WriteString(fmt.Sprintln(sol.ToList()))
-			b.WriteString(fmt.Sprintf("(%d trips, %d samples)\n", sol.Fitness.Trips, sol.Fitness.Samples))
+			if len(sol.Tours) > 0 {
+				b.WriteString(fmt.Sprintln("Tours"))
+				b.WriteString(fmt.Sprintln(sol.ToTours()))
+			}
+			b.WriteString(fmt.Sprintf("(%v)\n", sol.Fitness))
 			b.WriteString(fmt.Sprintln("------------------------------------------------------------"))
 		}
 
 	case "fitness":
 		for _, sol := range solution {
-			b.WriteString(fmt.Sprintf("(%d trips, %d samples)\n", sol.Fitness.Trips, sol.Fitness.Samples))
+			b.WriteString(fmt.Sprintf("(%v)\n", sol.Fitness))
+		}
+
+	case "manifest":
+		if len(problem.Labs) == 0 {
+			return "", fmt.Errorf("format 'manifest' requires a problem with labs")
+		}
+		for _, sol := range solution {
+			b.WriteString(fmt.Sprintln(sol.ToManifest()))
+			b.WriteString(fmt.Sprintf("(%v)\n", sol.Fitness))
+			b.WriteString(fmt.Sprintln("------------------------------------------------------------"))
 		}
 
 	default:
-		return "", fmt.Errorf("unknown format '%s'", format)
+		return "", fmt.Errorf("unknown format '%s'", output.format)
 	}
 
 	return b.String(), nil
@@ -1,29 +1,415 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestMain(t *testing.T) {
-	out, err := run("../../data/problem.json", "fitness", ",", true)
+	out, err := run(
+		&inputOptions{file: "../../data/problem.json"},
+		&outputOptions{format: "fitness", csvDelimiter: ",", pareto: true},
+	)
 	assert.Nil(t, err)
 	assert.Equal(t, "(5 trips, 1826 samples)\n", out)
 
-	_, err = run("../../data/problem.json", "json", ",", false)
+	_, err = run(
+		&inputOptions{file: "../../data/problem.json"},
+		&outputOptions{format: "json", csvDelimiter: ","},
+	)
 	assert.Nil(t, err)
 
-	_, err = run("../../data/problem.json", "table", ",", false)
+	_, err = run(
+		&inputOptions{file: "../../data/problem.json"},
+		&outputOptions{format: "table", csvDelimiter: ","},
+	)
 	assert.Nil(t, err)
 
-	_, err = run("../../data/problem.json", "csv", ",", false)
+	_, err = run(
+		&inputOptions{file: "../../data/problem.json"},
+		&outputOptions{format: "csv", csvDelimiter: ","},
+	)
 	assert.Nil(t, err)
 
-	_, err = run("../../data/problem.json", "list", ",", false)
+	_, err = run(
+		&inputOptions{file: "../../data/problem.json"},
+		&outputOptions{format: "list", csvDelimiter: ","},
+	)
 	assert.Nil(t, err)
 }
 
+func TestSites(t *testing.T) {
+	out, err := run(
+		&inputOptions{file: "../../data/sites.json"},
+		&outputOptions{format: "fitness", csvDelimiter: ",", pareto: true},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "(5 trips, 800 samples)\n", out)
+
+	out, err = run(
+		&inputOptions{file: "../../data/sites.json"},
+		&outputOptions{format: "fitness", csvDelimiter: ",", pareto: true, combineSites: true},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "(3 trips, 800 samples)\n", out)
+
+	out, err = runDescribe(&inputOptions{file: "../../data/sites.json"})
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Orchard B")
+}
+
+func TestLabs(t *testing.T) {
+	out, err := run(
+		&inputOptions{file: "../../data/labs.json"},
+		&outputOptions{format: "manifest", csvDelimiter: ","},
+	)
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Lab A (results at 3)")
+	assert.Contains(t, out, "200 x Pest 2")
+	assert.Contains(t, out, "Lab B (results at 2)")
+	assert.Contains(t, out, "250 x Pest 1")
+
+	out, err = run(
+		&inputOptions{file: "../../data/labs.json"},
+		&outputOptions{format: "csv", csvDelimiter: ","},
+	)
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Target,Lab\n")
+
+	_, err = run(
+		&inputOptions{file: "../../data/problem.json"},
+		&outputOptions{format: "manifest", csvDelimiter: ","},
+	)
+	assert.NotNil(t, err)
+
+	out, err = runDescribe(&inputOptions{file: "../../data/labs.json"})
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Pest 1, Pest 2")
+}
+
+func TestConstraints(t *testing.T) {
+	out, err := run(
+		&inputOptions{file: "../../data/constraints.json"},
+		&outputOptions{format: "list", csvDelimiter: ","},
+	)
+	assert.Nil(t, err)
+	assert.Contains(t, out, "(5 trips, 1826 samples)")
+	assert.Contains(t, out, "Time =  9:")
+	assert.NotContains(t, out, "Time =  8:")
+
+	out, err = runDescribe(&inputOptions{file: "../../data/constraints.json"})
+	assert.Nil(t, err)
+	assert.Contains(t, out, "at most 3 trips in times 3..7")
+	assert.Contains(t, out, "'Pest 1' before 'Pest 3'")
+}
+
+func TestCheck(t *testing.T) {
+	out, err := runCheck(&inputOptions{file: "../../data/problem.json"}, "../../data/replan/solution.json")
+	assert.Nil(t, err)
+	assert.Equal(t, "Solution 0: OK\n", out)
+
+	out, err = runCheck(&inputOptions{file: "../../data/constraints.json"}, "../../data/replan/solution.json")
+	assert.NotNil(t, err)
+	assert.Contains(t, out, "Solution 0: 1 violation(s)")
+	assert.Contains(t, out, "constraint: constraint not satisfied: not all of times 8..9")
+
+	_, err = runCheck(&inputOptions{file: "../../data/problem.json"}, "../../data/missing.json")
+	assert.NotNil(t, err)
+}
+
+func TestSolutionFile(t *testing.T) {
+	out, err := run(
+		&inputOptions{file: "../../data/constraints.json"},
+		&outputOptions{format: "json", csvDelimiter: ",", fitness: "trips", top: 2},
+	)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(out, "{\n    \"Version\": 1,"))
+
+	file := filepath.Join(t.TempDir(), "solution.json")
+	assert.Nil(t, os.WriteFile(file, []byte(out), 0644))
+
+	solutions, err := readSolutions(file)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(solutions.Solutions))
+	assert.Equal(t, 2, solutions.Settings.TopK)
+	assert.Equal(t, 3, len(solutions.Problem.Constraints))
+	assert.Greater(t, solutions.Stats.Nodes, 0)
+
+	out, err = runCheck(&inputOptions{}, file)
+	assert.Nil(t, err)
+	assert.Equal(t, "Solution 0: OK\nSolution 1: OK\n", out)
+
+	out, err = runReplan(file, "", 1, 5, &outputOptions{format: "fitness", csvDelimiter: ","})
+	assert.Nil(t, err)
+	assert.Contains(t, out, "(5 trips, 1826 samples)")
+}
+
+func TestCost(t *testing.T) {
+	out, err := run(
+		&inputOptions{file: "../../data/costs.json"},
+		&outputOptions{format: "table", csvDelimiter: ",", fitness: "cost"},
+	)
+	assert.Nil(t, err)
+	assert.Contains(t, out, "(38053.00 total: 880.00 trips, 4565.00 samples, 32608.00 assays; 5 trips, 1826 samples)")
+
+	_, err = run(
+		&inputOptions{file: "../../data/costs.json"},
+		&outputOptions{format: "table", csvDelimiter: ",", fitness: "cost", pareto: true},
+	)
+	assert.NotNil(t, err)
+
+	_, err = run(
+		&inputOptions{file: "../../data/problem.json"},
+		&outputOptions{format: "table", csvDelimiter: ",", fitness: "cost"},
+	)
+	assert.NotNil(t, err)
+}
+
+func TestTranspositionTable(t *testing.T) {
+	out, err := run(
+		&inputOptions{file: "../../data/pareto.json"},
+		&outputOptions{format: "fitness", csvDelimiter: ",", pareto: true, tableMB: 4, stats: true},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 9, strings.Count(out, "\n"))
+	assert.Contains(t, out, "(10 trips, 1000 samples)")
+}
+
+func TestDecompose(t *testing.T) {
+	expected, err := run(
+		&inputOptions{file: "../../data/sites.json"},
+		&outputOptions{format: "table", csvDelimiter: ","},
+	)
+	assert.Nil(t, err)
+
+	out, err := run(
+		&inputOptions{file: "../../data/sites.json"},
+		&outputOptions{format: "table", csvDelimiter: ",", decompose: true, stats: true},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, out)
+}
+
+func TestBranching(t *testing.T) {
+	for _, branching := range []string{"constrained", "largest", "capacity", "visited", "constrained,capacity"} {
+		out, err := run(
+			&inputOptions{file: "../../data/problem.json"},
+			&outputOptions{format: "fitness", csvDelimiter: ",", branching: branching},
+		)
+		assert.Nil(t, err)
+		assert.Contains(t, out, "(5 trips, 1826 samples)")
+	}
+
+	_, err := run(
+		&inputOptions{file: "../../data/problem.json"},
+		&outputOptions{format: "fitness", csvDelimiter: ",", branching: "foo"},
+	)
+	assert.NotNil(t, err)
+
+	_, err = run(
+		&inputOptions{file: "../../data/problem.json"},
+		&outputOptions{format: "fitness", csvDelimiter: ",", branching: "default,capacity,visited"},
+	)
+	assert.NotNil(t, err)
+}
+
+func TestAnytime(t *testing.T) {
+	expected, err := run(
+		&inputOptions{file: "../../data/sites.json"},
+		&outputOptions{format: "table", csvDelimiter: ","},
+	)
+	assert.Nil(t, err)
+
+	out, err := run(
+		&inputOptions{file: "../../data/sites.json"},
+		&outputOptions{format: "table", csvDelimiter: ",", anytime: true},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, out)
+}
+
+func TestTopK(t *testing.T) {
+	out, err := run(
+		&inputOptions{file: "../../data/problem.json"},
+		&outputOptions{format: "fitness", csvDelimiter: ",", top: 30},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 30, strings.Count(out, "\n"))
+	assert.True(t, strings.HasPrefix(out, "(5 trips, 1826 samples)"))
+
+	out, err = run(
+		&inputOptions{file: "../../data/problem.json"},
+		&outputOptions{format: "fitness", csvDelimiter: ",", tolerance: 0.25},
+	)
+	assert.Nil(t, err)
+	assert.Contains(t, out, "(6 trips, 1826 samples)")
+	assert.NotContains(t, out, "(7 trips")
+
+	_, err = run(
+		&inputOptions{file: "../../data/problem.json"},
+		&outputOptions{format: "fitness", csvDelimiter: ",", top: 5, pareto: true},
+	)
+	assert.NotNil(t, err)
+}
+
+func TestRoute(t *testing.T) {
+	out, err := run(
+		&inputOptions{file: "../../data/route.json"},
+		&outputOptions{format: "list", csvDelimiter: ",", fitness: "route"},
+	)
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Depot -> Orchard B -> Orchard A -> Depot")
+	assert.Contains(t, out, "(17.00 duration, 5 trips, 1000 samples)")
+
+	_, err = run(
+		&inputOptions{file: "../../data/route.json"},
+		&outputOptions{format: "list", csvDelimiter: ",", fitness: "route", pareto: true},
+	)
+	assert.NotNil(t, err)
+
+	_, err = run(
+		&inputOptions{file: "../../data/sites.json"},
+		&outputOptions{format: "list", csvDelimiter: ",", fitness: "route"},
+	)
+	assert.NotNil(t, err)
+
+	_, err = run(
+		&inputOptions{file: "../../data/sites.json"},
+		&outputOptions{format: "list", csvDelimiter: ",", fitness: "foo"},
+	)
+	assert.NotNil(t, err)
+}
+
+func TestTimeline(t *testing.T) {
+	out, err := run(
+		&inputOptions{file: "../../data/timeline.json"},
+		&outputOptions{format: "fitness", csvDelimiter: ",", pareto: true},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "(5 trips, 1826 samples)\n", out)
+
+	_, err = run(
+		&inputOptions{file: "../../data/timeline.json"},
+		&outputOptions{format: "list", csvDelimiter: ","},
+	)
+	assert.Nil(t, err)
+
+	for _, file := range []string{"../../data/timeline.yaml", "../../data/timeline.toml"} {
+		out, err := run(
+			&inputOptions{file: file},
+			&outputOptions{format: "fitness", csvDelimiter: ",", pareto: true},
+		)
+		assert.Nil(t, err)
+		assert.Equal(t, "(5 trips, 1826 samples)\n", out)
+	}
+}
+
+func TestPhenology(t *testing.T) {
+	input := inputOptions{
+		file:         "../../data/timeline.json",
+		phenology:    "../../data/phenology/phenology.json",
+		temperatures: "../../data/phenology/temperatures.csv",
+		csvDelimiter: ",",
+	}
+	out, err := run(&input, &outputOptions{format: "fitness", csvDelimiter: ",", pareto: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "(5 trips, 1826 samples)\n", out)
+
+	out, err = runDescribe(&input)
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Pest 1        41.0 ..   213.0 2024-03-18 .. 2024-04-13  2024-03-18..2024-04-08")
+
+	input.temperatures = ""
+	_, err = runDescribe(&input)
+	assert.NotNil(t, err)
+}
+
+func TestReplan(t *testing.T) {
+	out, err := runReplan("../../data/replan/solution.json", "../../data/replan/actuals.json", 0, -1, &outputOptions{format: "fitness", csvDelimiter: ","})
+	assert.Nil(t, err)
+	assert.Equal(t, "(5 trips, 1826 samples)\n(5 trips, 1826 samples)\n(5 trips, 1826 samples)\n(5 trips, 1826 samples)\n", out)
+
+	_, err = runReplan("../../data/replan/solution.json", "", 0, 5, &outputOptions{format: "json", csvDelimiter: ","})
+	assert.Nil(t, err)
+
+	_, err = runReplan("../../data/replan/solution.json", "", 1, 5, &outputOptions{format: "json", csvDelimiter: ","})
+	assert.NotNil(t, err)
+
+	_, err = runReplan("../../data/replan/solution.json", "", 0, -1, &outputOptions{format: "json", csvDelimiter: ","})
+	assert.NotNil(t, err)
+}
+
+func TestCSV(t *testing.T) {
+	input := inputOptions{
+		requirements: "../../data/csv/requirements.csv",
+		capacity:     "../../data/csv/capacity.csv",
+		matrices:     "../../data/csv/matrices.csv",
+		csvDelimiter: ",",
+	}
+	out, err := run(&input, &outputOptions{format: "fitness", csvDelimiter: ",", pareto: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "(5 trips, 1826 samples)\n", out)
+
+	out, err = runDescribe(&input)
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Timeline:   2024-03-04 .. 2024-05-20")
+
+	file := filepath.Join(t.TempDir(), "capacity.csv")
+	assert.Nil(t, os.WriteFile(file, []byte("Time;Capacity\n0..11;1000\n"), 0644))
+	out, err = run(
+		&inputOptions{file: "../../data/problem.json", capacity: file, csvDelimiter: ";"},
+		&outputOptions{format: "fitness", csvDelimiter: ";"},
+	)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(out, "(3 trips, 1826 samples)\n"))
+
+	_, err = run(
+		&inputOptions{requirements: "../../data/csv/requirements.csv", capacity: "../../data/csv/capacity.csv"},
+		&outputOptions{format: "fitness", csvDelimiter: ","},
+	)
+	assert.NotNil(t, err)
+
+	_, err = run(
+		&inputOptions{file: "../../data/problem.json", requirements: "../../data/csv/requirements.csv"},
+		&outputOptions{format: "fitness", csvDelimiter: ","},
+	)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "requirements.csv: line 2: times of subject 'Pest 1': ")
+}
+
+func TestConvert(t *testing.T) {
+	out, err := runConvert("../../data/timeline.json", "", "yaml")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(out, "Capacity: ["))
+
+	file := filepath.Join(t.TempDir(), "problem.toml")
+	out, err = runConvert("../../data/timeline.yaml", file, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "", out)
+
+	out, err = run(
+		&inputOptions{file: file},
+		&outputOptions{format: "fitness", csvDelimiter: ",", pareto: true},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "(5 trips, 1826 samples)\n", out)
+
+	_, err = runConvert("../../data/timeline.json", "", "")
+	assert.NotNil(t, err)
+	_, err = runConvert("../../data/timeline.json", "", "xml")
+	assert.NotNil(t, err)
+
+	bad := filepath.Join(t.TempDir(), "bad.yaml")
+	assert.Nil(t, os.WriteFile(bad, []byte("Capacity: [1, 2]\nRequirements:\n  - Subject: A\n    Samples: many\n"), 0644))
+	_, err = runConvert(bad, "", "json")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "bad.yaml: line 4, column 14: ")
+}
+
 func TestRootCommand(t *testing.T) {
 	_ = RootCommand()
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mlange-42/isso"
+	"github.com/spf13/cobra"
+)
+
+func replanCommand() *cobra.Command {
+	var output outputOptions
+	var file string
+	var actualsFile string
+	var index int
+	var time int
+
+	replan := &cobra.Command{
+		Use:   "replan",
+		Short: "Re-optimize the remaining time horizon of a previous solution",
+		Long: `Re-optimize the remaining time horizon of a previous solution.
+
+Takes the JSON output of a previous run, and optionally a JSON file with a list of actions
+that were actually taken. Planned samples before the current time are assumed to be
+collected as planned, except where actuals are given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := runReplan(file, actualsFile, index, time, &output)
+			if err != nil {
+				return err
+			}
+
+			fmt.Print(out)
+
+			return nil
+		},
+	}
+
+	replan.Flags().StringVarP(&file, "solution", "s", "", "Previous solution JSON file, as written with '--format json'")
+	replan.Flags().StringVarP(&actualsFile, "actuals", "a", "", "JSON file with a list of actions actually taken")
+	replan.Flags().IntVarP(&index, "index", "n", 0, "Index of the previous solution to use")
+	replan.Flags().IntVarP(&time, "time", "t", -1, "Current time step. Defaults to the time step after the latest actual")
+	output.addFlags(replan)
+	_ = replan.MarkFlagRequired("solution")
+
+	return replan
+}
+
+func runReplan(file, actualsFile string, index, time int, output *outputOptions) (string, error) {
+	solutions, err := readSolutions(file)
+	if err != nil {
+		return "", err
+	}
+	if index < 0 || index >= len(solutions.Solutions) {
+		return "", fmt.Errorf("solution index %d out of range; file contains %d solution(s)", index, len(solutions.Solutions))
+	}
+
+	actuals := []isso.Action{}
+	if actualsFile != "" {
+		jsData, err := os.ReadFile(actualsFile)
+		if err != nil {
+			return "", err
+		}
+		err = json.Unmarshal(jsData, &actuals)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if time < 0 {
+		if len(actuals) == 0 {
+			return "", fmt.Errorf("no actuals given; please specify the current time step")
+		}
+		for _, a := range actuals {
+			time = max(time, a.Time+1)
+		}
+	}
+
+	problem := isso.Replan(solutions.Problem, solutions.Solutions[index], actuals, time)
+
+	return solve(problem, output)
+}
+
+// readSolutions reads a solution file written with '--format json'.
+func readSolutions(file string) (isso.SolutionFile[json.RawMessage], error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return isso.SolutionFile[json.RawMessage]{}, err
+	}
+	defer f.Close()
+
+	return isso.ReadSolutions[json.RawMessage](f)
+}
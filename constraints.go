@@ -0,0 +1,316 @@
+package isso
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Constraint restricts the schedules of a problem. See [WithConstraints] and [ConstraintDef].
+//
+// Allow is consulted by the solver before an action is added, for pruning the search.
+// It may only reject an action if no complete schedule containing it and the current actions can be feasible.
+// Accept is consulted for each complete schedule, including fixed actions, and decides whether it is feasible.
+//
+// The actions of the current state are available through [SearchState.Actions],
+// and the samples covering each subject through [SearchState.Entries].
+type Constraint interface {
+	// Allow checks whether an action may be added in the given state.
+	Allow(state *SearchState, action *ActionDef) bool
+	// Accept checks whether the complete schedule of the given state is feasible.
+	Accept(state *SearchState) bool
+}
+
+// scoped is implemented by constraints that declare which requirements they couple,
+// so that decomposition can keep coupled requirements in the same sub-problem.
+// Problems with constraints that don't implement it are not decomposed. See [Problem.Decompose].
+type scoped interface {
+	// scope returns the subjects and times coupled by the constraint.
+	// Requirements of any of the subjects, or with any of the times, are coupled.
+	scope() ([]SubjectID, []int)
+}
+
+// ConstraintDef declares a constraint from the library of common constraints, as read from JSON.
+//
+// Supported types are:
+//   - "precedence": own samples of subject Before are taken before those of subject After. See [Precedence].
+//   - "max-matrices": at most Max different matrices are sampled per trip. See [MaxMatrices].
+//   - "forbidden-times": the given Times are not all visited. See [ForbiddenTimes].
+//   - "max-trips": at most Max trips in the given Times. See [MaxTrips].
+type ConstraintDef struct {
+	Type   string
+	Before string // Subject sampled first, for precedence.
+	After  string // Subject sampled last, for precedence.
+	Max    int    // Maximum number of matrices per trip, or of trips in the times.
+	Times  []int  // Times for forbidden-times and max-trips.
+}
+
+// Describe returns a short description of the constraint.
+// Times are formatted with the timeline, which may be nil. See [Timeline.Format].
+func (c *ConstraintDef) Describe(tl *Timeline) string {
+	switch c.Type {
+	case "precedence":
+		return fmt.Sprintf("'%s' before '%s'", c.Before, c.After)
+	case "max-matrices":
+		return fmt.Sprintf("at most %d matrices per trip", c.Max)
+	case "forbidden-times":
+		return fmt.Sprintf("not all of times %s", tl.Format(c.Times))
+	case "max-trips":
+		return fmt.Sprintf("at most %d trips in times %s", c.Max, tl.Format(c.Times))
+	default:
+		return c.Type
+	}
+}
+
+// newConstraint resolves a constraint definition by names to a constraint by IDs.
+func newConstraint(def *ConstraintDef, subjects map[string]SubjectID, numTimes int) (Constraint, error) {
+	times := slices.Clone(def.Times)
+	slices.Sort(times)
+	times = slices.Compact(times)
+	for _, t := range times {
+		if t < 0 || t >= numTimes {
+			return nil, fmt.Errorf("%s constraint has time %d out of range", def.Type, t)
+		}
+	}
+
+	switch def.Type {
+	case "precedence":
+		before, ok := subjects[def.Before]
+		if !ok {
+			return nil, fmt.Errorf("unknown subject '%v' in precedence constraint", def.Before)
+		}
+		after, ok := subjects[def.After]
+		if !ok {
+			return nil, fmt.Errorf("unknown subject '%v' in precedence constraint", def.After)
+		}
+		if before == after {
+			return nil, fmt.Errorf("precedence constraint for subject '%v' with itself", def.Before)
+		}
+		return &Precedence{Before: before, After: after}, nil
+	case "max-matrices":
+		if def.Max < 1 {
+			return nil, fmt.Errorf("max-matrices constraint requires a maximum of at least 1, got %d", def.Max)
+		}
+		return &MaxMatrices{Max: def.Max}, nil
+	case "forbidden-times":
+		if len(times) == 0 {
+			return nil, fmt.Errorf("forbidden-times constraint requires times")
+		}
+		return &ForbiddenTimes{Times: times}, nil
+	case "max-trips":
+		if def.Max < 0 {
+			return nil, fmt.Errorf("max-trips constraint requires a non-negative maximum, got %d", def.Max)
+		}
+		if len(times) == 0 {
+			return nil, fmt.Errorf("max-trips constraint requires times")
+		}
+		return &MaxTrips{Times: times, Max: def.Max}, nil
+	default:
+		return nil, fmt.Errorf("unknown constraint type '%s'", def.Type)
+	}
+}
+
+// Precedence requires all own samples of subject Before to be taken before any own sample of subject After.
+// Reused samples are not considered, as the solver assigns them to subjects automatically.
+type Precedence struct {
+	Before SubjectID
+	After  SubjectID
+}
+
+func (c *Precedence) Allow(state *SearchState, action *ActionDef) bool {
+	switch action.Subject {
+	case c.Before:
+		return c.first(state.Actions(), c.After) > action.Time
+	case c.After:
+		return c.last(state.Actions(), c.Before) < action.Time
+	}
+	return true
+}
+
+func (c *Precedence) Accept(state *SearchState) bool {
+	actions := state.Actions()
+	return c.last(actions, c.Before) < c.first(actions, c.After)
+}
+
+// first returns the first time of the subject's actions, or [math.MaxInt] if there are none.
+func (c *Precedence) first(actions []ActionDef, subject SubjectID) int {
+	first := math.MaxInt
+	for i := range actions {
+		if actions[i].Subject == subject {
+			first = min(first, actions[i].Time)
+		}
+	}
+	return first
+}
+
+// last returns the last time of the subject's actions, or -1 if there are none.
+func (c *Precedence) last(actions []ActionDef, subject SubjectID) int {
+	last := -1
+	for i := range actions {
+		if actions[i].Subject == subject {
+			last = max(last, actions[i].Time)
+		}
+	}
+	return last
+}
+
+func (c *Precedence) scope() ([]SubjectID, []int) {
+	return []SubjectID{c.Before, c.After}, nil
+}
+
+// MaxMatrices limits the number of different matrices sampled per trip, i.e. per site and time.
+type MaxMatrices struct {
+	Max int
+}
+
+func (c *MaxMatrices) Allow(state *SearchState, action *ActionDef) bool {
+	return c.count(state.Actions(), action.Site, action.Time, action.Matrix) <= c.Max
+}
+
+func (c *MaxMatrices) Accept(state *SearchState) bool {
+	actions := state.Actions()
+	for i := range actions {
+		if c.count(actions, actions[i].Site, actions[i].Time, actions[i].Matrix) > c.Max {
+			return false
+		}
+	}
+	return true
+}
+
+// count returns the number of different matrices sampled at a site and time, including the given matrix.
+func (c *MaxMatrices) count(actions []ActionDef, site SiteID, time int, matrix MatrixID) int {
+	matrices := []MatrixID{matrix}
+	for i := range actions {
+		a := &actions[i]
+		if a.Site == site && a.Time == time && !slices.Contains(matrices, a.Matrix) {
+			matrices = append(matrices, a.Matrix)
+		}
+	}
+	return len(matrices)
+}
+
+func (c *MaxMatrices) scope() ([]SubjectID, []int) {
+	return nil, nil
+}
+
+// ForbiddenTimes forbids sampling at all of the given times together.
+// Any subset of the times may be visited, at any site.
+type ForbiddenTimes struct {
+	Times []int
+}
+
+func (c *ForbiddenTimes) Allow(state *SearchState, action *ActionDef) bool {
+	if !slices.Contains(c.Times, action.Time) {
+		return true
+	}
+	return !c.allVisited(state.Actions(), action.Time)
+}
+
+func (c *ForbiddenTimes) Accept(state *SearchState) bool {
+	return !c.allVisited(state.Actions(), -1)
+}
+
+// allVisited checks whether all times are visited by the actions, or are the extra time.
+func (c *ForbiddenTimes) allVisited(actions []ActionDef, extra int) bool {
+	for _, t := range c.Times {
+		if t == extra {
+			continue
+		}
+		if !slices.ContainsFunc(actions, func(a ActionDef) bool { return a.Time == t }) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *ForbiddenTimes) scope() ([]SubjectID, []int) {
+	return nil, c.Times
+}
+
+// MaxTrips limits the number of trips in the given times.
+// Each visit of a site at a time counts as a trip.
+type MaxTrips struct {
+	Times []int
+	Max   int
+}
+
+func (c *MaxTrips) Allow(state *SearchState, action *ActionDef) bool {
+	if !slices.Contains(c.Times, action.Time) {
+		return true
+	}
+	actions := state.Actions()
+	for i := range actions {
+		if actions[i].Site == action.Site && actions[i].Time == action.Time {
+			return true
+		}
+	}
+	return c.trips(actions) < c.Max
+}
+
+func (c *MaxTrips) Accept(state *SearchState) bool {
+	return c.trips(state.Actions()) <= c.Max
+}
+
+// trips counts the trips in the times.
+func (c *MaxTrips) trips(actions []ActionDef) int {
+	type trip struct {
+		site SiteID
+		time int
+	}
+	trips := []trip{}
+	for i := range actions {
+		a := &actions[i]
+		tr := trip{a.Site, a.Time}
+		if slices.Contains(c.Times, a.Time) && !slices.Contains(trips, tr) {
+			trips = append(trips, tr)
+		}
+	}
+	return len(trips)
+}
+
+func (c *MaxTrips) scope() ([]SubjectID, []int) {
+	return nil, c.Times
+}
+
+// maxTourDuration limits the duration of the tour of each trip,
+// for problems with travel durations and a maximum tour duration.
+type maxTourDuration struct{}
+
+func (c maxTourDuration) Allow(state *SearchState, action *ActionDef) bool {
+	return state.Problem().tourFeasible(state.Actions(), action.Site, action.Time)
+}
+
+func (c maxTourDuration) Accept(state *SearchState) bool {
+	return state.Problem().toursFeasible(state.Actions())
+}
+
+func (c maxTourDuration) scope() ([]SubjectID, []int) {
+	return nil, nil
+}
+
+// describeConstraint returns a description of a constraint, for messages.
+// See [ConstraintDef.Describe].
+func (p *Problem) describeConstraint(c Constraint) string {
+	switch c := c.(type) {
+	case *Precedence:
+		def := ConstraintDef{Type: "precedence", Before: p.subjectNames[c.Before], After: p.subjectNames[c.After]}
+		return def.Describe(p.timeline)
+	case *MaxMatrices:
+		def := ConstraintDef{Type: "max-matrices", Max: c.Max}
+		return def.Describe(p.timeline)
+	case *ForbiddenTimes:
+		def := ConstraintDef{Type: "forbidden-times", Times: c.Times}
+		return def.Describe(p.timeline)
+	case *MaxTrips:
+		def := ConstraintDef{Type: "max-trips", Max: c.Max, Times: c.Times}
+		return def.Describe(p.timeline)
+	default:
+		return fmt.Sprintf("%T", c)
+	}
+}
+
+// Constraints returns the constraints of the problem,
+// including the constraint for the maximum tour duration of problems with travel durations.
+func (p *Problem) Constraints() []Constraint {
+	return slices.Clone(p.constraints)
+}
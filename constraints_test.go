@@ -0,0 +1,188 @@
+package isso_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+// acceptOnly wraps a constraint, and only checks complete schedules, without pruning.
+type acceptOnly struct {
+	isso.Constraint
+}
+
+func (c acceptOnly) Allow(state *isso.SearchState, action *isso.ActionDef) bool {
+	return true
+}
+
+// solveConstrained solves a problem with the given constraints, and returns the best fitness.
+func solveConstrained(t *testing.T, constraints ...isso.ConstraintDef) (fitness.TripsAndSamplesFitness, []isso.Action, bool) {
+	p := isso.NewProblem(isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}, {Name: "shoots"}},
+		Capacity: []int{100, 100, 100, 100},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Matrix: "fruits", Samples: 50, Times: []int{0, 1, 2}},
+			{Subject: "Pest 2", Matrix: "fruits", Samples: 30, Times: []int{1, 2, 3}},
+			{Subject: "Pest 3", Matrix: "shoots", Samples: 20, Times: []int{1, 2}},
+		},
+		Constraints: constraints,
+	})
+	s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{})
+	solutions, ok := s.Solve(&p)
+	if !ok {
+		return fitness.TripsAndSamplesFitness{}, nil, false
+	}
+	return solutions[0].Fitness, solutions[0].Actions, true
+}
+
+func TestConstraints(t *testing.T) {
+	fit, _, ok := solveConstrained(t)
+	assert.True(t, ok)
+	assert.Equal(t, fitness.TripsAndSamplesFitness{Trips: 1, Samples: 70}, fit)
+
+	fit, actions, ok := solveConstrained(t, isso.ConstraintDef{Type: "precedence", Before: "Pest 3", After: "Pest 1"})
+	assert.True(t, ok)
+	assert.Equal(t, fitness.TripsAndSamplesFitness{Trips: 2, Samples: 70}, fit)
+	times := map[string]int{}
+	for _, a := range actions {
+		times[a.Subject] = a.Time
+	}
+	assert.Less(t, times["Pest 3"], times["Pest 1"])
+
+	fit, _, ok = solveConstrained(t, isso.ConstraintDef{Type: "max-matrices", Max: 1})
+	assert.True(t, ok)
+	assert.Equal(t, fitness.TripsAndSamplesFitness{Trips: 2, Samples: 70}, fit)
+
+	fit, _, ok = solveConstrained(t, isso.ConstraintDef{Type: "forbidden-times", Times: []int{1, 2}})
+	assert.True(t, ok)
+	assert.Equal(t, fitness.TripsAndSamplesFitness{Trips: 1, Samples: 70}, fit)
+
+	fit, _, ok = solveConstrained(t,
+		isso.ConstraintDef{Type: "forbidden-times", Times: []int{1, 2}},
+		isso.ConstraintDef{Type: "max-matrices", Max: 1},
+	)
+	assert.True(t, ok)
+	assert.Equal(t, fitness.TripsAndSamplesFitness{Trips: 3, Samples: 100}, fit)
+
+	_, _, ok = solveConstrained(t,
+		isso.ConstraintDef{Type: "max-trips", Times: []int{0, 1, 2, 3}, Max: 1},
+		isso.ConstraintDef{Type: "max-matrices", Max: 1},
+	)
+	assert.False(t, ok)
+}
+
+func TestConstraintsPruning(t *testing.T) {
+	defs := []isso.ConstraintDef{
+		{Type: "precedence", Before: "Pest 0", After: "Pest 1"},
+		{Type: "max-matrices", Max: 1},
+		{Type: "forbidden-times", Times: []int{2, 3}},
+		{Type: "max-trips", Times: []int{0, 1, 2, 3, 4}, Max: 2},
+	}
+	for seed := int64(0); seed < 10; seed++ {
+		for i := range defs {
+			msg := fmt.Sprintf("seed %d, constraint %s", seed, defs[i].Type)
+
+			def := generateProblem(seed, 6, 10, 2)
+			free := isso.NewProblem(def)
+			def.Constraints = defs[i : i+1]
+			constrained := isso.NewProblem(def)
+
+			wrapped := []isso.Constraint{}
+			for _, c := range constrained.Constraints() {
+				wrapped = append(wrapped, acceptOnly{c})
+			}
+
+			for _, pareto := range []bool{false, true} {
+				var comp isso.Comparator[fitness.TripsAndSamplesFitness] = &fitness.TripsThenSamples{}
+				if pareto {
+					comp = &fitness.TripsSamplesPareto{}
+				}
+
+				s := isso.NewSolver(&fitness.TripsAndSamplesEvaluator{}, comp)
+				expected, ok := s.Solve(&constrained)
+
+				s = isso.NewSolver(&fitness.TripsAndSamplesEvaluator{}, comp, isso.WithConstraints(wrapped...))
+				solutions, okWrapped := s.Solve(&free)
+
+				s = isso.NewSolver(&fitness.TripsAndSamplesEvaluator{}, comp, isso.WithDecomposition())
+				decomposed, okDecomposed := s.Solve(&constrained)
+
+				assert.Equal(t, ok, okWrapped, msg)
+				assert.Equal(t, ok, okDecomposed, msg)
+				assert.Equal(t, fitnessSet(solutions), fitnessSet(expected), msg)
+				assert.Equal(t, fitnessSet(solutions), fitnessSet(decomposed), msg)
+			}
+		}
+	}
+}
+
+func TestConstraintsDecompose(t *testing.T) {
+	def := isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}},
+		Capacity: []int{100, 100, 100, 100, 100, 100},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Matrix: "fruits", Samples: 50, Times: []int{0, 1}},
+			{Subject: "Pest 2", Matrix: "fruits", Samples: 50, Times: []int{2, 3}},
+			{Subject: "Pest 3", Matrix: "fruits", Samples: 50, Times: []int{4, 5}},
+		},
+	}
+	p := isso.NewProblem(def)
+	assert.Equal(t, 3, len(p.Decompose()))
+
+	def.Constraints = []isso.ConstraintDef{{Type: "precedence", Before: "Pest 3", After: "Pest 1"}}
+	p = isso.NewProblem(def)
+	assert.Equal(t, 2, len(p.Decompose()))
+
+	def.Constraints = []isso.ConstraintDef{{Type: "max-trips", Times: []int{1, 2}, Max: 1}}
+	p = isso.NewProblem(def)
+	assert.Equal(t, 2, len(p.Decompose()))
+
+	def.Constraints = []isso.ConstraintDef{{Type: "max-matrices", Max: 1}}
+	p = isso.NewProblem(def)
+	assert.Equal(t, 3, len(p.Decompose()))
+
+	s := isso.NewSolver[fitness.TripsAndSamplesFitness](
+		&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{},
+		isso.WithDecomposition(), isso.WithConstraints(acceptOnly{&isso.MaxMatrices{Max: 1}}),
+	)
+	_, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, []int{3}, s.Stats().Components)
+}
+
+func TestConstraintsUnmarshal(t *testing.T) {
+	js := `{
+		"Matrices": [{"Name": "fruits", "CanReuse": []}],
+		"Capacity": [100, 100, 100, 100],
+		"Timeline": {"Start": "2024-05-01", "Step": "week"},
+		"Requirements": [
+			{"Subject": "Pest 1", "Matrix": "fruits", "Samples": 100, "Times": [0, 1, 2, 3]}
+		],
+		"Constraints": [
+			{"Type": "max-trips", "Max": 1, "Times": ["2024-05-08..2024-05-15", 3]},
+			{"Type": "max-matrices", "Max": 1}
+		]
+	}`
+
+	problem := isso.ProblemDef{}
+	err := json.Unmarshal([]byte(js), &problem)
+	assert.Nil(t, err)
+	assert.Equal(t, []isso.ConstraintDef{
+		{Type: "max-trips", Max: 1, Times: []int{1, 2, 3}},
+		{Type: "max-matrices", Max: 1, Times: []int{}},
+	}, problem.Constraints)
+
+	p := isso.NewProblem(problem)
+	assert.Equal(t, []isso.Constraint{
+		&isso.MaxTrips{Times: []int{1, 2, 3}, Max: 1},
+		&isso.MaxMatrices{Max: 1},
+	}, p.Constraints())
+
+	js = `{"Constraints": [{"Type": "max-trips", "Times": [true]}]}`
+	err = json.Unmarshal([]byte(js), &problem)
+	assert.NotNil(t, err)
+}
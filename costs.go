@@ -0,0 +1,90 @@
+package isso
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Costs define prices for cost-based fitness evaluation.
+type Costs struct {
+	Trip    float64            // Cost per trip.
+	Trips   []float64          // Optional cost per trip for each time step, e.g. for weekends. Overrides Trip.
+	Samples map[string]float64 // Collection cost per sample, by matrix.
+	// Lab cost per assay, by subject.
+	// Subjects without an entry are charged the cost of the cheapest lab that can analyse them, see [Lab.Cost].
+	Assays map[string]float64
+}
+
+// CostTable holds the prices of a problem, indexed by time step, matrix and subject IDs.
+type CostTable struct {
+	Trips   []float64 // Cost per trip, by time step.
+	Samples []float64 // Collection cost per sample, by matrix ID.
+	Assays  []float64 // Lab cost per assay, by subject ID.
+}
+
+// Trip returns the cost of a trip at the given time step.
+func (c *CostTable) Trip(time int) float64 {
+	return c.Trips[time]
+}
+
+// Sample returns the collection cost of a sample of the given matrix.
+func (c *CostTable) Sample(m MatrixID) float64 {
+	return c.Samples[m]
+}
+
+// Assay returns the lab cost of an assay for the given subject.
+func (c *CostTable) Assay(s SubjectID) float64 {
+	return c.Assays[s]
+}
+
+// newCostTable resolves costs by names to a cost table by IDs.
+// Assay costs not given by the costs are taken from the cheapest lab for the subject.
+func newCostTable(costs *Costs, labs []Lab, numTimes int, matrices map[string]MatrixID, subjects map[string]SubjectID) (*CostTable, error) {
+	if len(costs.Trips) > numTimes {
+		return nil, fmt.Errorf("costs have %d trip costs, but there are only %d time steps", len(costs.Trips), numTimes)
+	}
+	table := CostTable{
+		Trips:   make([]float64, numTimes),
+		Samples: make([]float64, len(matrices)),
+		Assays:  make([]float64, len(subjects)),
+	}
+	for t := range table.Trips {
+		if t < len(costs.Trips) {
+			table.Trips[t] = costs.Trips[t]
+		} else {
+			table.Trips[t] = costs.Trip
+		}
+	}
+	for name, cost := range costs.Samples {
+		id, ok := matrices[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown matrix '%v' in sample costs", name)
+		}
+		table.Samples[id] = cost
+	}
+	for name, cost := range costs.Assays {
+		id, ok := subjects[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown subject '%v' in assay costs", name)
+		}
+		table.Assays[id] = cost
+	}
+	for name, id := range subjects {
+		if _, ok := costs.Assays[name]; ok {
+			continue
+		}
+		found := false
+		for i := range labs {
+			if slices.Contains(labs[i].Subjects, name) && (!found || labs[i].Cost < table.Assays[id]) {
+				table.Assays[id] = labs[i].Cost
+				found = true
+			}
+		}
+	}
+	return &table, nil
+}
+
+// Costs returns the cost table of the problem, or nil if the problem defines no costs.
+func (p *Problem) Costs() *CostTable {
+	return p.costs
+}
@@ -0,0 +1,392 @@
+package isso
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ReadRequirements reads requirements from CSV, replacing the requirements of the problem.
+//
+// The first row is a header. Required columns are Subject, Matrix, Samples and Times, column Site is optional.
+// Times are a list of entries separated by commas or semicolons, each accepted by [Timeline.Parse],
+// like "2, 3, 5..8" or "2024-05-01..2024-06-15". Dates and labels require the problem's timeline.
+// Each subject requires at least one time, and times must not overlap.
+// Read capacity before requirements if it defines the timeline. See [ProblemDef.ReadCapacity].
+// Times must be within the time steps of capacity read before.
+func (p *ProblemDef) ReadRequirements(r io.Reader, delim rune) error {
+	table, err := readCSVTable(r, delim, "requirements")
+	if err != nil {
+		return err
+	}
+	cols, err := table.require("Subject", "Matrix", "Samples", "Times")
+	if err != nil {
+		return err
+	}
+	subjectCol, matrixCol, samplesCol, timesCol := cols[0], cols[1], cols[2], cols[3]
+	siteCol, hasSite := table.columns["site"]
+
+	requirements := make([]Requirement, 0, len(table.rows))
+	subjects := map[string]bool{}
+	for i, row := range table.rows {
+		line := table.lines[i]
+		req := Requirement{
+			Subject: strings.TrimSpace(row[subjectCol]),
+			Matrix:  strings.TrimSpace(row[matrixCol]),
+		}
+		if req.Subject == "" {
+			return fmt.Errorf("line %d: missing subject", line)
+		}
+		if subjects[req.Subject] {
+			return fmt.Errorf("line %d: duplicate subject '%s'", line, req.Subject)
+		}
+		subjects[req.Subject] = true
+
+		if req.Samples, err = parseCount(row[samplesCol]); err != nil {
+			return fmt.Errorf("line %d: invalid samples '%s'", line, row[samplesCol])
+		}
+		req.Times = []int{}
+		for _, entry := range splitList(row[timesCol]) {
+			times, err := p.Timeline.Parse(entry)
+			if err != nil {
+				return fmt.Errorf("line %d: times of subject '%s': %s", line, req.Subject, err.Error())
+			}
+			for _, t := range times {
+				if slices.Contains(req.Times, t) {
+					return fmt.Errorf("line %d: duplicate time %d of subject '%s'", line, t, req.Subject)
+				}
+				req.Times = append(req.Times, t)
+			}
+		}
+		if len(req.Times) == 0 {
+			return fmt.Errorf("line %d: missing times of subject '%s'", line, req.Subject)
+		}
+		if err := p.checkTimes(req.Times); err != nil {
+			return fmt.Errorf("line %d: times of subject '%s': %s", line, req.Subject, err.Error())
+		}
+		if hasSite {
+			req.Site = strings.TrimSpace(row[siteCol])
+		}
+		requirements = append(requirements, req)
+	}
+
+	p.Requirements = requirements
+	return nil
+}
+
+// ReadCapacity reads the capacity per time step from CSV, replacing the capacity and sites of the problem.
+//
+// The first row is a header. Required columns are Time, and Capacity for problems without sites,
+// or one capacity column per site, named by the site.
+// Time entries are accepted by [Timeline.Parse]. The capacity of a range applies to each of its time steps.
+// Time steps that are not given have zero capacity, up to the number of timeline labels
+// and the latest time of the problem's requirements.
+//
+// If the problem has no timeline and times are not indices, they are used as timeline labels, in the given order.
+func (p *ProblemDef) ReadCapacity(r io.Reader, delim rune) error {
+	table, err := readCSVTable(r, delim, "capacity")
+	if err != nil {
+		return err
+	}
+	cols, err := table.require("Time")
+	if err != nil {
+		return err
+	}
+	timeCol := cols[0]
+	if len(table.rows) == 0 {
+		return fmt.Errorf("no time steps in capacity file")
+	}
+
+	valueCols := []int{}
+	for i := range table.header {
+		if i != timeCol {
+			valueCols = append(valueCols, i)
+		}
+	}
+	if len(valueCols) == 0 {
+		return fmt.Errorf("capacity file requires column 'Capacity', or one column per site")
+	}
+	hasSites := len(valueCols) > 1 || !strings.EqualFold(table.header[valueCols[0]], "capacity")
+
+	timeline := p.Timeline
+	if timeline == nil && !table.allTimes(timeCol, nil) {
+		timeline = &Timeline{}
+		for i, row := range table.rows {
+			label := strings.TrimSpace(row[timeCol])
+			if label == "" || slices.Contains(timeline.Labels, label) {
+				return fmt.Errorf("line %d: empty or duplicate time label '%s'", table.lines[i], label)
+			}
+			timeline.Labels = append(timeline.Labels, label)
+		}
+	}
+
+	capacity := make([][]int, len(valueCols))
+	given := []bool{}
+	for i, row := range table.rows {
+		line := table.lines[i]
+		times, err := timeline.Parse(row[timeCol])
+		if err != nil {
+			return fmt.Errorf("line %d: %s", line, err.Error())
+		}
+		for _, t := range times {
+			for len(given) <= t {
+				given = append(given, false)
+			}
+			if given[t] {
+				return fmt.Errorf("line %d: duplicate time step %d", line, t)
+			}
+			given[t] = true
+		}
+
+		for j, col := range valueCols {
+			value := 0
+			if strings.TrimSpace(row[col]) != "" {
+				if value, err = parseCount(row[col]); err != nil {
+					return fmt.Errorf("line %d: invalid capacity '%s'", line, row[col])
+				}
+			}
+			for len(capacity[j]) < len(given) {
+				capacity[j] = append(capacity[j], 0)
+			}
+			for _, t := range times {
+				capacity[j][t] = value
+			}
+		}
+	}
+
+	if hasSites {
+		sites := make([]Site, len(valueCols))
+		for j, col := range valueCols {
+			sites[j] = Site{Name: table.header[col], Capacity: capacity[j]}
+		}
+		p.Capacity = nil
+		p.Sites = sites
+	} else {
+		p.Capacity = capacity[0]
+		p.Sites = nil
+	}
+	p.Timeline = timeline
+	p.padCapacity()
+	return nil
+}
+
+// padCapacity pads the capacity of the problem or of its sites with zeros,
+// up to the number of timeline labels and the latest requirement time.
+func (p *ProblemDef) padCapacity() {
+	numTimes := 0
+	if p.Timeline != nil {
+		numTimes = len(p.Timeline.Labels)
+	}
+	for _, r := range p.Requirements {
+		for _, t := range r.Times {
+			numTimes = max(numTimes, t+1)
+		}
+	}
+
+	pad := func(capacity []int) []int {
+		for len(capacity) < numTimes {
+			capacity = append(capacity, 0)
+		}
+		return capacity
+	}
+	if p.Capacity != nil {
+		p.Capacity = pad(p.Capacity)
+	}
+	for i := range p.Sites {
+		p.Sites[i].Capacity = pad(p.Sites[i].Capacity)
+	}
+}
+
+// ReadMatrices reads matrices and their reuse from CSV, replacing the matrices of the problem.
+//
+// The table is either a list or an adjacency matrix.
+// A list has columns Matrix and CanReuse, with CanReuse as matrix names separated by commas or semicolons.
+// A matrix can have multiple rows. Matrices are in the order of their first row.
+// An adjacency matrix has matrix names in the header row and the first column.
+// A cell that is not empty, "0", "no" or "false" means that samples of the column's matrix
+// can be used for the row's matrix. Cells on the diagonal are ignored.
+func (p *ProblemDef) ReadMatrices(r io.Reader, delim rune) error {
+	table, err := readCSVTable(r, delim, "matrices")
+	if err != nil {
+		return err
+	}
+
+	var matrices []Matrix
+	if _, ok := table.columns["canreuse"]; ok || len(table.header) == 1 {
+		matrices, err = table.matrixList()
+	} else {
+		matrices, err = table.matrixAdjacency()
+	}
+	if err != nil {
+		return err
+	}
+
+	p.Matrices = matrices
+	return nil
+}
+
+// matrixList reads matrices from a list with columns Matrix and CanReuse.
+func (t *csvTable) matrixList() ([]Matrix, error) {
+	cols, err := t.require("Matrix")
+	if err != nil {
+		return nil, err
+	}
+	nameCol := cols[0]
+	reuseCol, hasReuse := t.columns["canreuse"]
+
+	matrices := []Matrix{}
+	index := map[string]int{}
+	lines := map[string]int{}
+	for i, row := range t.rows {
+		name := strings.TrimSpace(row[nameCol])
+		if name == "" {
+			return nil, fmt.Errorf("line %d: missing matrix", t.lines[i])
+		}
+		idx, ok := index[name]
+		if !ok {
+			idx = len(matrices)
+			index[name] = idx
+			matrices = append(matrices, Matrix{Name: name, CanReuse: []string{}})
+		}
+		if !hasReuse {
+			continue
+		}
+		for _, reuse := range splitList(row[reuseCol]) {
+			if !slices.Contains(matrices[idx].CanReuse, reuse) {
+				matrices[idx].CanReuse = append(matrices[idx].CanReuse, reuse)
+				lines[reuse] = t.lines[i]
+			}
+		}
+	}
+
+	for _, m := range matrices {
+		for _, reuse := range m.CanReuse {
+			if _, ok := index[reuse]; !ok {
+				return nil, fmt.Errorf("line %d: unknown matrix '%s'", lines[reuse], reuse)
+			}
+		}
+	}
+	return matrices, nil
+}
+
+// matrixAdjacency reads matrices from an adjacency matrix.
+func (t *csvTable) matrixAdjacency() ([]Matrix, error) {
+	matrices := make([]Matrix, 0, len(t.rows))
+	for i, row := range t.rows {
+		name := strings.TrimSpace(row[0])
+		if name == "" || slices.ContainsFunc(matrices, func(m Matrix) bool { return m.Name == name }) {
+			return nil, fmt.Errorf("line %d: empty or duplicate matrix '%s'", t.lines[i], name)
+		}
+		m := Matrix{Name: name, CanReuse: []string{}}
+		for col := 1; col < len(row); col++ {
+			if t.header[col] == name {
+				continue
+			}
+			switch strings.ToLower(strings.TrimSpace(row[col])) {
+			case "", "0", "no", "false":
+			default:
+				m.CanReuse = append(m.CanReuse, t.header[col])
+			}
+		}
+		matrices = append(matrices, m)
+	}
+
+	for col := 1; col < len(t.header); col++ {
+		if !slices.ContainsFunc(matrices, func(m Matrix) bool { return m.Name == t.header[col] }) {
+			return nil, fmt.Errorf("column %d: matrix '%s' has no row", col+1, t.header[col])
+		}
+	}
+	return matrices, nil
+}
+
+// csvTable is a CSV table with a header row.
+type csvTable struct {
+	name    string         // Name of the table, for error messages.
+	header  []string       // Trimmed column names.
+	columns map[string]int // Column indices by lower-case name.
+	rows    [][]string
+	lines   []int // Line of each row, for error messages.
+}
+
+// readCSVTable reads a CSV table with a header row. Name is used in error messages.
+func readCSVTable(r io.Reader, delim rune, name string) (*csvTable, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delim
+	reader.TrimLeadingSpace = true
+
+	table := csvTable{name: name, columns: map[string]int{}}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if table.header == nil {
+			for i, c := range row {
+				c = strings.TrimSpace(strings.TrimPrefix(c, "\ufeff")) // Byte order mark of spreadsheet exports.
+				table.header = append(table.header, c)
+				table.columns[strings.ToLower(c)] = i
+			}
+			continue
+		}
+		table.rows = append(table.rows, row)
+		table.lines = append(table.lines, line)
+	}
+	if table.header == nil {
+		return nil, fmt.Errorf("empty %s file", name)
+	}
+	return &table, nil
+}
+
+// require returns the indices of the given columns, or an error if any is missing.
+func (t *csvTable) require(names ...string) ([]int, error) {
+	cols := make([]int, len(names))
+	for i, name := range names {
+		col, ok := t.columns[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("missing column '%s' in %s file", name, t.name)
+		}
+		cols[i] = col
+	}
+	return cols, nil
+}
+
+// allTimes checks whether all entries of a column are accepted by the given timeline.
+func (t *csvTable) allTimes(col int, timeline *Timeline) bool {
+	for _, row := range t.rows {
+		if _, err := timeline.Parse(row[col]); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// splitList splits a list of entries separated by commas or semicolons, and drops empty entries.
+func splitList(s string) []string {
+	entries := []string{}
+	for _, e := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		if e = strings.TrimSpace(e); e != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// parseCount parses a non-negative integer.
+func parseCount(s string) (int, error) {
+	v, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 {
+		return 0, fmt.Errorf("negative value %d", v)
+	}
+	return v, nil
+}
@@ -0,0 +1,192 @@
+package isso_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCSV(t *testing.T) {
+	expected := readProblemFile(t, "data/timeline.json")
+
+	problem := isso.ProblemDef{}
+	for _, tt := range []struct {
+		file string
+		read func(f *os.File) error
+	}{
+		{"data/csv/capacity.csv", func(f *os.File) error { return problem.ReadCapacity(f, ',') }},
+		{"data/csv/matrices.csv", func(f *os.File) error { return problem.ReadMatrices(f, ',') }},
+		{"data/csv/requirements.csv", func(f *os.File) error { return problem.ReadRequirements(f, ',') }},
+	} {
+		f, err := os.Open(tt.file)
+		assert.Nil(t, err)
+		assert.Nil(t, tt.read(f), tt.file)
+		f.Close()
+	}
+
+	assert.Equal(t, expected.Capacity, problem.Capacity)
+	assert.Equal(t, expected.Requirements, problem.Requirements)
+	assert.Equal(t, len(expected.Matrices), len(problem.Matrices))
+	for i, m := range expected.Matrices {
+		assert.Equal(t, m.Name, problem.Matrices[i].Name)
+		assert.ElementsMatch(t, m.CanReuse, problem.Matrices[i].CanReuse)
+	}
+	assert.Equal(t, 12, len(problem.Timeline.Labels))
+	assert.Equal(t, "2024-03-18", problem.Timeline.Labels[2])
+
+	p := isso.NewProblem(problem)
+	assert.Equal(t, 12, p.NumTimes())
+}
+
+func TestReadRequirements(t *testing.T) {
+	problem := isso.ProblemDef{Timeline: &isso.Timeline{Start: "2024-05-01", Step: "week"}}
+	err := problem.ReadRequirements(strings.NewReader(
+		"\ufeffsubject;Matrix;Samples;Times;Site\nPest 1;fruits;100;0, 2..3; A\nPest 2;shoots;50;2024-05-08..2024-05-15;B\n"), ';')
+	assert.Nil(t, err)
+	assert.Equal(t, []isso.Requirement{
+		{Subject: "Pest 1", Matrix: "fruits", Samples: 100, Times: []int{0, 2, 3}, Site: "A"},
+		{Subject: "Pest 2", Matrix: "shoots", Samples: 50, Times: []int{1, 2}, Site: "B"},
+	}, problem.Requirements)
+
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"", "empty requirements file"},
+		{"Subject,Matrix,Samples\nPest 1,fruits,100\n", "missing column 'Times' in requirements file"},
+		{"Subject,Matrix,Samples,Times\nPest 1,fruits,many,0\n", "line 2: invalid samples 'many'"},
+		{"Subject,Matrix,Samples,Times\nPest 1,fruits,-1,0\n", "line 2: invalid samples '-1'"},
+		{"Subject,Matrix,Samples,Times\nPest 1,fruits,1,0\nPest 1,fruits,1,0\n", "line 3: duplicate subject 'Pest 1'"},
+		{"Subject,Matrix,Samples,Times\n,fruits,1,0\n", "line 2: missing subject"},
+		{"Subject,Matrix,Samples,Times\nPest 1,fruits,1,2024-04-01\n", "line 2: times of subject 'Pest 1': "},
+		{"Subject,Matrix,Samples,Times\nPest 1,fruits,1\n", "record on line 2: wrong number of fields"},
+		{"Subject,Matrix,Samples,Times\nPest 1,fruits,1,0\nPest 2,fruits,1,\n", "line 3: missing times of subject 'Pest 2'"},
+		{"Subject,Matrix,Samples,Times\nPest 1,fruits,1,\"0..3, 2\"\n", "line 2: duplicate time 2 of subject 'Pest 1'"},
+	}
+	for _, tt := range tests {
+		problem := isso.ProblemDef{Timeline: &isso.Timeline{Start: "2024-05-01", Step: "week"}}
+		err := problem.ReadRequirements(strings.NewReader(tt.input), ',')
+		if assert.NotNil(t, err, tt.input) {
+			assert.Contains(t, err.Error(), tt.err)
+		}
+	}
+}
+
+func TestReadCapacity(t *testing.T) {
+	problem := isso.ProblemDef{}
+	err := problem.ReadCapacity(strings.NewReader("Time,Capacity\n0..2,100\n4,\n5,50\n"), ',')
+	assert.Nil(t, err)
+	assert.Equal(t, []int{100, 100, 100, 0, 0, 50}, problem.Capacity)
+	assert.Nil(t, problem.Sites)
+	assert.Nil(t, problem.Timeline)
+
+	problem = isso.ProblemDef{Capacity: []int{1}}
+	err = problem.ReadCapacity(strings.NewReader("Time,Site A,Site B\nKW18,100,0\nKW19,200,50\n"), ',')
+	assert.Nil(t, err)
+	assert.Nil(t, problem.Capacity)
+	assert.Equal(t, []isso.Site{
+		{Name: "Site A", Capacity: []int{100, 200}},
+		{Name: "Site B", Capacity: []int{0, 50}},
+	}, problem.Sites)
+	assert.Equal(t, &isso.Timeline{Labels: []string{"KW18", "KW19"}}, problem.Timeline)
+
+	problem = isso.ProblemDef{Timeline: &isso.Timeline{Start: "2024-05-01", Step: "week"}}
+	err = problem.ReadCapacity(strings.NewReader("Time,Capacity\n2024-05-08..2024-05-15,100\n"), ',')
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 100, 100}, problem.Capacity)
+
+	problem = readProblemFile(t, "data/timeline.json")
+	err = problem.ReadCapacity(strings.NewReader("Time,Capacity\n2024-03-04..2024-04-01,100\n"), ',')
+	assert.Nil(t, err)
+	assert.Equal(t, []int{100, 100, 100, 100, 100, 0, 0, 0, 0, 0, 0, 0}, problem.Capacity)
+	p := isso.NewProblem(problem)
+	assert.Equal(t, 12, p.NumTimes())
+
+	problem = isso.ProblemDef{Timeline: &isso.Timeline{Labels: []string{"KW18", "KW19", "KW20"}}}
+	err = problem.ReadCapacity(strings.NewReader("Time,Site A,Site B\nKW18,100,0\n"), ',')
+	assert.Nil(t, err)
+	assert.Equal(t, []int{100, 0, 0}, problem.Sites[0].Capacity)
+	assert.Equal(t, []int{0, 0, 0}, problem.Sites[1].Capacity)
+	err = problem.ReadRequirements(strings.NewReader("Subject,Matrix,Samples,Times,Site\nPest 1,fruits,100,3,Site A\n"), ',')
+	assert.Equal(t, "line 2: times of subject 'Pest 1': time 3 is beyond the last time step KW20", err.Error())
+
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"Time,Capacity\n", "no time steps in capacity file"},
+		{"Capacity\n100\n", "missing column 'Time' in capacity file"},
+		{"Time\n0\n", "capacity file requires column 'Capacity'"},
+		{"Time,Capacity\n0,100\n0..1,100\n", "line 3: duplicate time step 0"},
+		{"Time,Capacity\n0,many\n", "line 2: invalid capacity 'many'"},
+		{"Time,Capacity\nKW18,100\nKW18,100\n", "line 3: empty or duplicate time label 'KW18'"},
+		{"Time,Capacity\n-1,100\n", "line 2: negative time '-1'"},
+	}
+	for _, tt := range tests {
+		problem := isso.ProblemDef{}
+		err := problem.ReadCapacity(strings.NewReader(tt.input), ',')
+		if assert.NotNil(t, err, tt.input) {
+			assert.Contains(t, err.Error(), tt.err)
+		}
+	}
+}
+
+func TestReadMatrices(t *testing.T) {
+	expected := []isso.Matrix{
+		{Name: "fruits & shoots", CanReuse: []string{}},
+		{Name: "fruits | shoots", CanReuse: []string{"fruits", "shoots", "fruits & shoots"}},
+		{Name: "fruits", CanReuse: []string{"fruits & shoots"}},
+		{Name: "shoots", CanReuse: []string{"fruits & shoots"}},
+	}
+
+	problem := isso.ProblemDef{}
+	err := problem.ReadMatrices(strings.NewReader(`Matrix,CanReuse
+fruits & shoots,
+fruits | shoots,"fruits; shoots"
+fruits,fruits & shoots
+shoots,fruits & shoots
+fruits | shoots,fruits & shoots
+`), ',')
+	assert.Nil(t, err)
+	assert.Equal(t, expected, problem.Matrices)
+
+	err = problem.ReadMatrices(strings.NewReader(`;fruits;shoots;fruits & shoots;fruits | shoots
+fruits & shoots;x;0;;no
+fruits | shoots;1;yes;true;1
+fruits;;;X;
+shoots;false;;x;
+`), ';')
+	assert.Nil(t, err)
+	assert.Equal(t, []isso.Matrix{
+		{Name: "fruits & shoots", CanReuse: []string{"fruits"}},
+		{Name: "fruits | shoots", CanReuse: []string{"fruits", "shoots", "fruits & shoots"}},
+		{Name: "fruits", CanReuse: []string{"fruits & shoots"}},
+		{Name: "shoots", CanReuse: []string{"fruits & shoots"}},
+	}, problem.Matrices)
+
+	err = problem.ReadMatrices(strings.NewReader("Matrix\nfruits\nshoots\n"), ',')
+	assert.Nil(t, err)
+	assert.Equal(t, []isso.Matrix{{Name: "fruits", CanReuse: []string{}}, {Name: "shoots", CanReuse: []string{}}}, problem.Matrices)
+
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"", "empty matrices file"},
+		{"Name,CanReuse\nfruits,\n", "missing column 'Matrix' in matrices file"},
+		{"Matrix,CanReuse\nfruits,shoots\n", "line 2: unknown matrix 'shoots'"},
+		{"Matrix,CanReuse\n,fruits\n", "line 2: missing matrix"},
+		{",fruits,shoots\nfruits,,x\n", "column 3: matrix 'shoots' has no row"},
+		{",fruits\nfruits,\nfruits,\n", "line 3: empty or duplicate matrix 'fruits'"},
+	}
+	for _, tt := range tests {
+		problem := isso.ProblemDef{}
+		err := problem.ReadMatrices(strings.NewReader(tt.input), ',')
+		if assert.NotNil(t, err, tt.input) {
+			assert.Contains(t, err.Error(), tt.err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package isso
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Decompose splits the problem into independent sub-problems.
+//
+// Requirements with overlapping times, including the times of their fixed actions, may share capacity,
+// samples and trips. They are connected in an interaction graph, even for matrices that can't reuse each other,
+// as they still compete for capacity. Connected components of the graph are independent sub-problems,
+// which never share any time steps.
+//
+// Constraints that couple requirements, like precedence or a maximum of trips in a time window,
+// keep the coupled requirements in the same sub-problem. All sub-problems have all constraints of the problem.
+//
+// Sub-problems keep the subject, matrix and site IDs of the problem.
+// Returns a single sub-problem if the problem can't be decomposed.
+func (p *Problem) Decompose() []Problem {
+	return p.decompose(nil)
+}
+
+// decompose splits the problem into independent sub-problems, considering additional constraints.
+// The problem is not decomposed if any constraint does not declare its scope.
+func (p *Problem) decompose(constraints []Constraint) []Problem {
+	constraints = append(slices.Clone(p.constraints), constraints...)
+	for _, c := range constraints {
+		if _, ok := c.(scoped); !ok {
+			return []Problem{*p}
+		}
+	}
+
+	times := make([]bitset, len(p.requirements))
+	index := map[SubjectID]int{}
+	for i := range p.requirements {
+		times[i] = slices.Clone(p.requirements[i].Window)
+		index[p.requirements[i].Subject] = i
+	}
+	for i := range p.fixed {
+		a := &p.fixed[i]
+		times[index[a.Subject]].Set(a.Time)
+	}
+
+	parent := make([]int, len(p.requirements))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range times {
+		for j := i + 1; j < len(times); j++ {
+			if times[i].Intersects(times[j]) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+	for _, c := range constraints {
+		subjects, scopeTimes := c.(scoped).scope()
+		window := newBitset(scopeTimes)
+		first := -1
+		for i := range p.requirements {
+			if !slices.Contains(subjects, p.requirements[i].Subject) && !times[i].Intersects(window) {
+				continue
+			}
+			if first < 0 {
+				first = i
+			} else {
+				parent[find(i)] = find(first)
+			}
+		}
+	}
+
+	components := map[int]int{}
+	problems := []Problem{}
+	for i := range p.requirements {
+		root := find(i)
+		c, ok := components[root]
+		if !ok {
+			c = len(problems)
+			components[root] = c
+			sub := *p
+			sub.requirements = []requirement{}
+			sub.fixed = []ActionDef{}
+			problems = append(problems, sub)
+		}
+		problems[c].requirements = append(problems[c].requirements, p.requirements[i])
+	}
+	for i := range p.fixed {
+		a := &p.fixed[i]
+		c := components[find(index[a.Subject])]
+		problems[c].fixed = append(problems[c].fixed, *a)
+	}
+
+	return problems
+}
+
+// solveComponents solves independent sub-problems, and combines their solutions.
+func (s *search[F]) solveComponents(problem *Problem, components []Problem) {
+	parts := make([][]solution[F], len(components))
+	for i := range components {
+		s.runProblem(&components[i])
+		if len(s.solutions) == 0 {
+			s.problem = problem
+			return
+		}
+		parts[i] = s.solutions
+	}
+
+	combined := []solution[F]{{}}
+	for _, part := range parts {
+		next := make([]solution[F], 0, len(combined)*len(part))
+		for _, c := range combined {
+			for _, sol := range part {
+				next = append(next, solution[F]{
+					Actions:   append(slices.Clone(c.Actions), sol.Actions...),
+					Decisions: append(slices.Clone(c.Decisions), sol.Decisions...),
+				})
+			}
+		}
+		combined = next
+	}
+
+	var zero F
+	s.bestFitness = zero
+	s.seeded = false
+	s.problem = problem
+	s.solutions = []solution[F]{}
+	if pa, ok := s.evaluator.(ProblemAware); ok {
+		pa.Init(problem)
+	}
+
+	for _, sol := range combined {
+		slices.SortStableFunc(sol.Actions, func(a, b ActionDef) int {
+			return cmp.Compare(a.Subject, b.Subject)
+		})
+		s.accept(s.evaluator.Evaluate(sol.Decisions), func() solution[F] { return sol })
+	}
+}
@@ -0,0 +1,107 @@
+package isso_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecompose(t *testing.T) {
+	p := isso.NewProblem(isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}, {Name: "shoots"}},
+		Capacity: []int{100, 100, 100, 100, 100, 100},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Matrix: "fruits", Samples: 50, Times: []int{0, 1}},
+			{Subject: "Pest 2", Matrix: "shoots", Samples: 50, Times: []int{1, 2}},
+			{Subject: "Pest 3", Matrix: "fruits", Samples: 50, Times: []int{4, 5}},
+			{Subject: "Pest 4", Matrix: "fruits", Samples: 50, Times: []int{3}},
+		},
+		FixedActions: []isso.Action{
+			{Subject: "Pest 4", Matrix: "fruits", Samples: 20, Time: 4},
+		},
+	})
+
+	components := p.Decompose()
+	assert.Equal(t, 2, len(components))
+
+	subjects := [][]string{}
+	for _, c := range components {
+		names := []string{}
+		for _, r := range c.Requirements() {
+			names = append(names, r.Subject)
+		}
+		subjects = append(subjects, names)
+	}
+	assert.Equal(t, [][]string{{"Pest 1", "Pest 2"}, {"Pest 3", "Pest 4"}}, subjects)
+
+	s := isso.NewSolver[fitness.TripsAndSamplesFitness](
+		&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{},
+		isso.WithDecomposition(),
+	)
+	solutions, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, []int{2, 2}, s.Stats().Components)
+	assert.Equal(t, fitness.TripsAndSamplesFitness{Trips: 3, Samples: 200}, solutions[0].Fitness)
+
+	subjectOrder := []string{}
+	for _, a := range solutions[0].Actions {
+		subjectOrder = append(subjectOrder, a.Subject)
+	}
+	assert.True(t, slices.IsSorted(subjectOrder))
+}
+
+func TestSolveDecomposed(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		p := isso.NewProblem(generateProblem(seed, 6, 16, 2))
+
+		for _, pareto := range []bool{false, true} {
+			var comp isso.Comparator[fitness.TripsAndSamplesFitness] = &fitness.TripsThenSamples{}
+			if pareto {
+				comp = &fitness.TripsSamplesPareto{}
+			}
+			msg := fmt.Sprintf("seed %d, pareto %v", seed, pareto)
+
+			s := isso.NewSolver(&fitness.TripsAndSamplesEvaluator{}, comp)
+			expected, ok := s.Solve(&p)
+
+			s = isso.NewSolver(&fitness.TripsAndSamplesEvaluator{}, comp, isso.WithDecomposition())
+			solutions, okDecomposed := s.Solve(&p)
+
+			assert.Equal(t, ok, okDecomposed, msg)
+			assert.Equal(t, len(p.Requirements()), sum(s.Stats().Components), msg)
+			if !ok {
+				continue
+			}
+			assert.Equal(t, fitnessSet(expected), fitnessSet(solutions), msg)
+		}
+	}
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// fitnessSet returns the distinct fitness values of solutions, sorted.
+func fitnessSet(solutions []isso.Solution[fitness.TripsAndSamplesFitness]) []fitness.TripsAndSamplesFitness {
+	set := []fitness.TripsAndSamplesFitness{}
+	for _, sol := range solutions {
+		if !slices.Contains(set, sol.Fitness) {
+			set = append(set, sol.Fitness)
+		}
+	}
+	slices.SortFunc(set, func(a, b fitness.TripsAndSamplesFitness) int {
+		if a.Trips != b.Trips {
+			return a.Trips - b.Trips
+		}
+		return a.Samples - b.Samples
+	})
+	return set
+}
@@ -0,0 +1,177 @@
+package fitness
+
+import (
+	"cmp"
+	"math"
+
+	"github.com/mlange-42/isso"
+)
+
+// Key extracts an objective value from a fitness, for building comparators.
+// Lower values are better.
+type Key[F any] func(F) float64
+
+// Weight is a weighted objective for [WeightedSum].
+type Weight[F any] struct {
+	Key    Key[F]
+	Weight float64
+}
+
+// Lexicographic creates a comparator that compares by the given keys in order of priority.
+//
+// A fitness with all keys zero is treated as the empty incumbent, and any fitness compares better than it.
+// The comparator is [isso.Tolerant], with the tolerance applied to each key.
+func Lexicographic[F any](keys ...Key[F]) isso.Comparator[F] {
+	return &lexicographic[F]{keys: keys}
+}
+
+// WeightedSum creates a comparator that compares by the weighted sum of the given objectives.
+//
+// A fitness with all keys zero is treated as the empty incumbent, and any fitness compares better than it.
+// The comparator is [isso.Tolerant], with the tolerance applied to the weighted sum.
+func WeightedSum[F any](weights ...Weight[F]) isso.Comparator[F] {
+	return &weightedSum[F]{weights: weights}
+}
+
+// Pareto creates a pareto comparator over the given keys.
+// A fitness is better than another if it is not worse in any key, and better in at least one.
+//
+// A fitness with all keys zero is treated as the empty incumbent, and any fitness compares better than it.
+func Pareto[F any](keys ...Key[F]) isso.Comparator[F] {
+	return &pareto[F]{keys: keys}
+}
+
+// equalKeys checks whether all keys of two fitness values are equal.
+func equalKeys[F any](a, b F, keys []Key[F]) bool {
+	for _, k := range keys {
+		if k(a) != k(b) {
+			return false
+		}
+	}
+	return true
+}
+
+// within checks whether value a is not larger than value b by more than the relative tolerance.
+func within(a, b, tolerance float64) bool {
+	return a <= b+tolerance*math.Abs(b)
+}
+
+// isEmpty checks whether all keys are zero, i.e. whether the fitness is the empty incumbent.
+func isEmpty[F any](f F, keys []Key[F]) bool {
+	for _, k := range keys {
+		if k(f) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type lexicographic[F any] struct {
+	keys []Key[F]
+}
+
+func (c *lexicographic[F]) Compare(a, b F) int {
+	if isEmpty(b, c.keys) {
+		return -1
+	}
+	for _, k := range c.keys {
+		if r := cmp.Compare(k(a), k(b)); r != 0 {
+			return r
+		}
+	}
+	return 0
+}
+
+func (c *lexicographic[F]) Equal(a, b F) bool {
+	return equalKeys(a, b, c.keys)
+}
+
+func (c *lexicographic[F]) Within(a, b F, tolerance float64) bool {
+	for _, k := range c.keys {
+		if !within(k(a), k(b), tolerance) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *lexicographic[F]) IsPareto() bool {
+	return false
+}
+
+type weightedSum[F any] struct {
+	weights []Weight[F]
+}
+
+func (c *weightedSum[F]) Compare(a, b F) int {
+	empty := true
+	sumA, sumB := 0.0, 0.0
+	for _, w := range c.weights {
+		vb := w.Key(b)
+		if vb != 0 {
+			empty = false
+		}
+		sumA += w.Weight * w.Key(a)
+		sumB += w.Weight * vb
+	}
+	if empty {
+		return -1
+	}
+	return cmp.Compare(sumA, sumB)
+}
+
+func (c *weightedSum[F]) Equal(a, b F) bool {
+	for _, w := range c.weights {
+		if w.Key(a) != w.Key(b) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *weightedSum[F]) Within(a, b F, tolerance float64) bool {
+	sumA, sumB := 0.0, 0.0
+	for _, w := range c.weights {
+		sumA += w.Weight * w.Key(a)
+		sumB += w.Weight * w.Key(b)
+	}
+	return within(sumA, sumB, tolerance)
+}
+
+func (c *weightedSum[F]) IsPareto() bool {
+	return false
+}
+
+type pareto[F any] struct {
+	keys []Key[F]
+}
+
+func (c *pareto[F]) Compare(a, b F) int {
+	if isEmpty(b, c.keys) {
+		return -1
+	}
+	better, worse := false, false
+	for _, k := range c.keys {
+		switch cmp.Compare(k(a), k(b)) {
+		case -1:
+			better = true
+		case 1:
+			worse = true
+		}
+	}
+	if better && !worse {
+		return -1
+	}
+	if worse && !better {
+		return 1
+	}
+	return 0
+}
+
+func (c *pareto[F]) Equal(a, b F) bool {
+	return equalKeys(a, b, c.keys)
+}
+
+func (c *pareto[F]) IsPareto() bool {
+	return true
+}
@@ -0,0 +1,88 @@
+package fitness_test
+
+import (
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+type tsf = fitness.TripsAndSamplesFitness
+
+func trips(f tsf) float64   { return float64(f.Trips) }
+func samples(f tsf) float64 { return float64(f.Samples) }
+
+func fitnessGrid() []tsf {
+	values := []tsf{}
+	for tr := 0; tr < 4; tr++ {
+		for s := 0; s < 400; s += 100 {
+			values = append(values, tsf{Trips: tr, Samples: s})
+		}
+	}
+	return values
+}
+
+func TestLexicographic(t *testing.T) {
+	comp := fitness.Lexicographic(trips, samples)
+	ref := fitness.TripsThenSamples{}
+
+	assert.False(t, comp.IsPareto())
+	for _, a := range fitnessGrid() {
+		for _, b := range fitnessGrid() {
+			assert.Equal(t, ref.Compare(a, b), comp.Compare(a, b), "%v vs. %v", a, b)
+			assert.Equal(t, ref.Equal(a, b), comp.Equal(a, b), "%v vs. %v", a, b)
+			assert.Equal(t, ref.Within(a, b, 0.5), comp.(isso.Tolerant[tsf]).Within(a, b, 0.5), "%v vs. %v", a, b)
+		}
+	}
+}
+
+func TestPareto(t *testing.T) {
+	comp := fitness.Pareto(trips, samples)
+	ref := fitness.TripsSamplesPareto{}
+
+	assert.True(t, comp.IsPareto())
+	for _, a := range fitnessGrid() {
+		for _, b := range fitnessGrid() {
+			assert.Equal(t, ref.Compare(a, b), comp.Compare(a, b), "%v vs. %v", a, b)
+			assert.Equal(t, ref.Equal(a, b), comp.Equal(a, b), "%v vs. %v", a, b)
+		}
+	}
+}
+
+func TestWeightedSum(t *testing.T) {
+	comp := fitness.WeightedSum(
+		fitness.Weight[tsf]{Key: trips, Weight: 100},
+		fitness.Weight[tsf]{Key: samples, Weight: 1},
+	)
+
+	assert.False(t, comp.IsPareto())
+	assert.Equal(t, -1, comp.Compare(tsf{Trips: 5, Samples: 1000}, tsf{}))
+	assert.Equal(t, -1, comp.Compare(tsf{Trips: 2, Samples: 100}, tsf{Trips: 1, Samples: 250}))
+	assert.Equal(t, 0, comp.Compare(tsf{Trips: 2, Samples: 100}, tsf{Trips: 1, Samples: 200}))
+	assert.Equal(t, 1, comp.Compare(tsf{Trips: 2, Samples: 100}, tsf{Trips: 1, Samples: 150}))
+
+	assert.True(t, comp.Equal(tsf{Trips: 2, Samples: 100}, tsf{Trips: 2, Samples: 100}))
+	assert.False(t, comp.Equal(tsf{Trips: 2, Samples: 100}, tsf{Trips: 1, Samples: 200}))
+
+	tolerant := comp.(isso.Tolerant[tsf])
+	assert.True(t, tolerant.Within(tsf{Trips: 2, Samples: 100}, tsf{Trips: 1, Samples: 200}, 0))
+	assert.True(t, tolerant.Within(tsf{Trips: 2, Samples: 120}, tsf{Trips: 1, Samples: 200}, 0.1))
+	assert.False(t, tolerant.Within(tsf{Trips: 2, Samples: 140}, tsf{Trips: 1, Samples: 200}, 0.1))
+}
+
+func TestCombinatorsSolve(t *testing.T) {
+	p := isso.NewProblem(isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}},
+		Capacity: []int{100, 100, 100},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Matrix: "fruits", Samples: 150, Times: []int{0, 1, 2}},
+			{Subject: "Pest 2", Matrix: "fruits", Samples: 100, Times: []int{1, 2}},
+		},
+	})
+
+	s := isso.NewSolver(&fitness.TripsAndSamplesEvaluator{}, fitness.Lexicographic(trips, samples))
+	solution, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, tsf{Trips: 2, Samples: 150}, solution[0].Fitness)
+}
@@ -0,0 +1,131 @@
+package fitness
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/mlange-42/isso"
+)
+
+// CostFitness is the fitness of a solution with cost-based evaluation.
+type CostFitness struct {
+	Total      float64 // Total cost.
+	TripCost   float64 // Cost of all trips.
+	SampleCost float64 // Collection cost of all own samples.
+	AssayCost  float64 // Lab cost of all assays, for the required samples of all subjects.
+	Trips      int
+	Samples    int
+}
+
+func (f CostFitness) String() string {
+	return fmt.Sprintf("%.2f total: %.2f trips, %.2f samples, %.2f assays; %d trips, %d samples",
+		f.Total, f.TripCost, f.SampleCost, f.AssayCost, f.Trips, f.Samples)
+}
+
+// CostEvaluator evaluates the total cost of trips, sample collection and lab assays.
+//
+// Trips are counted like in [TripsAndSamplesEvaluator].
+// Collection costs apply to own samples.
+// Assay costs apply to the required samples of each subject, no matter whether they are own or reused samples.
+// As all subjects are covered by a solution, assay costs are the same for all solutions of a problem.
+//
+// CostEvaluator is [isso.ProblemAware], and is initialized by the solver. It is an [isso.Cloner].
+type CostEvaluator struct {
+	CombineSites bool
+	costs        *isso.CostTable
+	assays       float64 // Assay cost of all requirements.
+	times        [][]bool
+}
+
+// NewCostEvaluator creates a new CostEvaluator for a problem with costs.
+func NewCostEvaluator(p *isso.Problem) *CostEvaluator {
+	e := &CostEvaluator{}
+	e.Init(p)
+	return e
+}
+
+// Init initializes the evaluator for a problem with costs.
+func (e *CostEvaluator) Init(p *isso.Problem) {
+	costs := p.Costs()
+	if costs == nil {
+		panic("cost evaluator requires a problem with costs")
+	}
+	e.costs = costs
+	e.assays = 0
+	for _, r := range p.Requirements() {
+		id, _ := p.SubjectID(r.Subject)
+		e.assays += float64(r.Samples) * costs.Assay(id)
+	}
+	e.times = e.times[:0]
+}
+
+// Clone returns a new evaluator with the same settings and costs.
+func (e *CostEvaluator) Clone() isso.Evaluator[CostFitness] {
+	return &CostEvaluator{
+		CombineSites: e.CombineSites,
+		costs:        e.costs,
+		assays:       e.assays,
+	}
+}
+
+func (e *CostEvaluator) Evaluate(sol []isso.ActionDef) CostFitness {
+	for i := range e.times {
+		clear(e.times[i])
+	}
+	f := CostFitness{AssayCost: e.assays}
+	for _, a := range sol {
+		if a.Reuse >= 0 {
+			continue
+		}
+		f.Samples += a.Samples
+		f.SampleCost += float64(a.Samples) * e.costs.Sample(a.Matrix)
+
+		site := int(a.Site)
+		if e.CombineSites {
+			site = 0
+		}
+		for len(e.times) <= site {
+			e.times = append(e.times, []bool{})
+		}
+		for len(e.times[site]) <= a.Time {
+			e.times[site] = append(e.times[site], false)
+		}
+		if !e.times[site][a.Time] {
+			e.times[site][a.Time] = true
+			f.Trips++
+			f.TripCost += e.costs.Trip(a.Time)
+		}
+	}
+	f.Total = f.TripCost + f.SampleCost + f.AssayCost
+
+	return f
+}
+
+// LowestCost compares by total cost first, by trips second and by samples third.
+type LowestCost struct{}
+
+func (e *LowestCost) Compare(a, b CostFitness) int {
+	if b.Trips == 0 && b.Samples == 0 {
+		return -1
+	}
+	if c := cmp.Compare(a.Total, b.Total); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Trips, b.Trips); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Samples, b.Samples)
+}
+
+func (e *LowestCost) Equal(a, b CostFitness) bool {
+	return a == b
+}
+
+// Within checks whether the total cost of a is within the tolerance of that of b.
+func (e *LowestCost) Within(a, b CostFitness, tolerance float64) bool {
+	return within(a.Total, b.Total, tolerance)
+}
+
+func (e *LowestCost) IsPareto() bool {
+	return false
+}
@@ -0,0 +1,85 @@
+package fitness_test
+
+import (
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCostEvaluator(t *testing.T) {
+	p := isso.NewProblem(isso.ProblemDef{
+		Matrices: []isso.Matrix{
+			{Name: "fruits & shoots"},
+			{Name: "fruits", CanReuse: []string{"fruits & shoots"}},
+		},
+		Sites: []isso.Site{
+			{Name: "A", Capacity: []int{100, 100, 100}},
+			{Name: "B", Capacity: []int{100, 100, 100}},
+		},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Site: "A", Matrix: "fruits & shoots", Samples: 100, Times: []int{0, 1}},
+			{Subject: "Pest 2", Site: "A", Matrix: "fruits", Samples: 50, Times: []int{0, 1}},
+			{Subject: "Pest 3", Site: "B", Matrix: "fruits", Samples: 100, Times: []int{0, 1, 2}},
+		},
+		Costs: &isso.Costs{
+			Trip:    100,
+			Trips:   []float64{100, 300},
+			Samples: map[string]float64{"fruits & shoots": 2, "fruits": 1},
+			Assays:  map[string]float64{"Pest 1": 10, "Pest 2": 5},
+		},
+	})
+
+	solution := []isso.ActionDef{
+		{Subject: 0, Matrix: 0, Site: 0, Time: 0, Samples: 100, Reuse: -1},
+		{Subject: 1, Matrix: 1, Site: 0, Time: 0, Samples: 50, Reuse: 0},
+		{Subject: 2, Matrix: 1, Site: 1, Time: 1, Samples: 60, Reuse: -1},
+		{Subject: 2, Matrix: 1, Site: 1, Time: 2, Samples: 40, Reuse: -1},
+	}
+
+	eval := fitness.NewCostEvaluator(&p)
+	fit := eval.Evaluate(solution)
+	assert.Equal(t, fitness.CostFitness{
+		Total:      500 + 300 + 1250,
+		TripCost:   100 + 300 + 100,
+		SampleCost: 200 + 60 + 40,
+		AssayCost:  1000 + 250,
+		Trips:      3,
+		Samples:    200,
+	}, fit)
+	assert.Equal(t, fit, eval.Evaluate(solution))
+	assert.Equal(t, "2050.00 total: 500.00 trips, 300.00 samples, 1250.00 assays; 3 trips, 200 samples", fit.String())
+
+	// Assays of subjects covered by reuse are charged, whether or not their actions are evaluated.
+	own := []isso.ActionDef{solution[0], solution[2], solution[3]}
+	assert.Equal(t, fit, eval.Evaluate(own))
+	assert.Equal(t, 1250.0, eval.Clone().Evaluate(own).AssayCost)
+
+	eval.CombineSites = true
+	fit = eval.Evaluate(solution[:3])
+	assert.Equal(t, 2, fit.Trips)
+	assert.Equal(t, 400.0, fit.TripCost)
+
+	assert.Panics(t, func() {
+		p := isso.NewProblem(isso.ProblemDef{})
+		fitness.NewCostEvaluator(&p)
+	})
+}
+
+func TestLowestCost(t *testing.T) {
+	comp := fitness.LowestCost{}
+	type cf = fitness.CostFitness
+
+	assert.False(t, comp.IsPareto())
+
+	assert.Equal(t, -1, comp.Compare(cf{Total: 500, Trips: 1, Samples: 100}, cf{}))
+	assert.Equal(t, -1, comp.Compare(cf{Total: 500, Trips: 2, Samples: 100}, cf{Total: 600, Trips: 1, Samples: 50}))
+	assert.Equal(t, -1, comp.Compare(cf{Total: 500, Trips: 1, Samples: 100}, cf{Total: 500, Trips: 2, Samples: 50}))
+	assert.Equal(t, -1, comp.Compare(cf{Total: 500, Trips: 1, Samples: 50}, cf{Total: 500, Trips: 1, Samples: 100}))
+	assert.Equal(t, 0, comp.Compare(cf{Total: 500, Trips: 1, Samples: 50}, cf{Total: 500, Trips: 1, Samples: 50}))
+	assert.Equal(t, 1, comp.Compare(cf{Total: 600, Trips: 1, Samples: 50}, cf{Total: 500, Trips: 1, Samples: 100}))
+
+	assert.True(t, comp.Within(cf{Total: 550, Trips: 5}, cf{Total: 500, Trips: 1}, 0.1))
+	assert.False(t, comp.Within(cf{Total: 551, Trips: 1}, cf{Total: 500, Trips: 1}, 0.1))
+}
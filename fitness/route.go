@@ -0,0 +1,140 @@
+package fitness
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/route"
+)
+
+// RouteFitness is the fitness of a solution with route-aware trip costs.
+type RouteFitness struct {
+	Duration float64 // Total duration of all tours.
+	Trips    int     // Number of trips, i.e. time steps with any site visited.
+	Samples  int
+}
+
+func (f RouteFitness) String() string {
+	return fmt.Sprintf("%.2f duration, %d trips, %d samples", f.Duration, f.Trips, f.Samples)
+}
+
+// RouteEvaluator evaluates the total duration of the tours of all trips.
+//
+// All sites visited at the same time step are combined into a single tour.
+// Tours are solved exactly for small numbers of sites, and heuristically for larger ones.
+// See package [route] for details.
+//
+// RouteEvaluator is [isso.ProblemAware], and is initialized by the solver. It is an [isso.Cloner].
+type RouteEvaluator struct {
+	durations [][]float64
+	visits    [][]int
+	cache     map[uint64]float64
+}
+
+// NewRouteEvaluator creates a new RouteEvaluator for a problem with travel durations.
+func NewRouteEvaluator(p *isso.Problem) *RouteEvaluator {
+	e := &RouteEvaluator{}
+	e.Init(p)
+	return e
+}
+
+// Init initializes the evaluator for a problem with travel durations.
+func (e *RouteEvaluator) Init(p *isso.Problem) {
+	travel := p.Travel()
+	if travel == nil {
+		panic("route evaluator requires a problem with travel durations")
+	}
+	e.durations = travel.Durations
+	e.visits = e.visits[:0]
+	e.cache = map[uint64]float64{}
+}
+
+// Clone returns a new evaluator for the same travel durations, with an empty cache.
+func (e *RouteEvaluator) Clone() isso.Evaluator[RouteFitness] {
+	return &RouteEvaluator{
+		durations: e.durations,
+		cache:     map[uint64]float64{},
+	}
+}
+
+func (e *RouteEvaluator) Evaluate(sol []isso.ActionDef) RouteFitness {
+	for i := range e.visits {
+		e.visits[i] = e.visits[i][:0]
+	}
+	samples := 0
+	for _, a := range sol {
+		if a.Reuse >= 0 {
+			continue
+		}
+		samples += a.Samples
+		for len(e.visits) <= a.Time {
+			e.visits = append(e.visits, []int{})
+		}
+		if node := int(a.Site) + 1; !slices.Contains(e.visits[a.Time], node) {
+			e.visits[a.Time] = append(e.visits[a.Time], node)
+		}
+	}
+
+	trips := 0
+	duration := 0.0
+	for _, nodes := range e.visits {
+		if len(nodes) == 0 {
+			continue
+		}
+		trips++
+		duration += e.tourDuration(nodes)
+	}
+
+	return RouteFitness{
+		Duration: duration,
+		Trips:    trips,
+		Samples:  samples,
+	}
+}
+
+// tourDuration returns the duration of a tour over the given nodes, using a cache for up to 64 nodes.
+func (e *RouteEvaluator) tourDuration(nodes []int) float64 {
+	if len(e.durations) > 64 {
+		_, d := route.Tour(e.durations, nodes)
+		return d
+	}
+	var key uint64
+	for _, n := range nodes {
+		key |= 1 << (n - 1)
+	}
+	if d, ok := e.cache[key]; ok {
+		return d
+	}
+	_, d := route.Tour(e.durations, nodes)
+	e.cache[key] = d
+	return d
+}
+
+// RouteThenSamples compares by total tour duration first, and by samples second.
+type RouteThenSamples struct{}
+
+func (e *RouteThenSamples) Compare(a, b RouteFitness) int {
+	if b.Trips == 0 && b.Samples == 0 {
+		return -1
+	}
+	if c := cmp.Compare(a.Duration, b.Duration); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Samples, b.Samples)
+}
+
+func (e *RouteThenSamples) Equal(a, b RouteFitness) bool {
+	return a == b
+}
+
+// Within checks whether duration and samples of a are both within the tolerance of those of b.
+func (e *RouteThenSamples) Within(a, b RouteFitness, tolerance float64) bool {
+	return within(a.Duration, b.Duration, tolerance) &&
+		within(float64(a.Samples), float64(b.Samples), tolerance)
+}
+
+func (e *RouteThenSamples) IsPareto() bool {
+	return false
+}
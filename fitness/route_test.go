@@ -0,0 +1,69 @@
+package fitness_test
+
+import (
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteEvaluator(t *testing.T) {
+	p := isso.NewProblem(isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}},
+		Sites: []isso.Site{
+			{Name: "A", Capacity: []int{100, 100}},
+			{Name: "B", Capacity: []int{100, 100}},
+		},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Site: "A", Matrix: "fruits", Samples: 100, Times: []int{0, 1}},
+			{Subject: "Pest 2", Site: "B", Matrix: "fruits", Samples: 100, Times: []int{0, 1}},
+		},
+		Travel: &isso.Travel{
+			Depot: "Depot",
+			Durations: [][]float64{
+				{0, 1, 2},
+				{1, 0, 2},
+				{2, 2, 0},
+			},
+		},
+	})
+
+	solution := []isso.ActionDef{
+		{Subject: 0, Site: 0, Time: 0, Samples: 100, Reuse: -1},
+		{Subject: 1, Site: 1, Time: 0, Samples: 50, Reuse: -1},
+		{Subject: 1, Site: 1, Time: 1, Samples: 50, Reuse: -1},
+	}
+
+	eval := fitness.NewRouteEvaluator(&p)
+	fit := eval.Evaluate(solution)
+	assert.Equal(t, fitness.RouteFitness{Duration: 9, Trips: 2, Samples: 200}, fit)
+	assert.Equal(t, fit, eval.Evaluate(solution))
+	assert.Equal(t, "9.00 duration, 2 trips, 200 samples", fit.String())
+
+	assert.Panics(t, func() {
+		p := isso.NewProblem(isso.ProblemDef{})
+		fitness.NewRouteEvaluator(&p)
+	})
+
+	s := isso.NewSolver[fitness.RouteFitness](&fitness.RouteEvaluator{}, &fitness.RouteThenSamples{})
+	sol, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, fitness.RouteFitness{Duration: 5, Trips: 1, Samples: 200}, sol[0].Fitness)
+}
+
+func TestRouteThenSamples(t *testing.T) {
+	comp := fitness.RouteThenSamples{}
+	type rf = fitness.RouteFitness
+
+	assert.False(t, comp.IsPareto())
+
+	assert.Equal(t, -1, comp.Compare(rf{Duration: 5, Trips: 1, Samples: 100}, rf{}))
+	assert.Equal(t, -1, comp.Compare(rf{Duration: 5, Trips: 2, Samples: 100}, rf{Duration: 6, Trips: 1, Samples: 50}))
+	assert.Equal(t, -1, comp.Compare(rf{Duration: 5, Trips: 1, Samples: 50}, rf{Duration: 5, Trips: 1, Samples: 100}))
+	assert.Equal(t, 0, comp.Compare(rf{Duration: 5, Trips: 1, Samples: 50}, rf{Duration: 5, Trips: 2, Samples: 50}))
+	assert.Equal(t, 1, comp.Compare(rf{Duration: 6, Trips: 1, Samples: 50}, rf{Duration: 5, Trips: 1, Samples: 100}))
+
+	assert.True(t, comp.Within(rf{Duration: 5.5, Trips: 3, Samples: 110}, rf{Duration: 5, Trips: 1, Samples: 100}, 0.1))
+	assert.False(t, comp.Within(rf{Duration: 6, Trips: 1, Samples: 100}, rf{Duration: 5, Trips: 1, Samples: 100}, 0.1))
+}
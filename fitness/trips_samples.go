@@ -2,6 +2,7 @@ package fitness
 
 import (
 	"cmp"
+	"fmt"
 
 	"github.com/mlange-42/isso"
 )
@@ -11,27 +12,60 @@ type TripsAndSamplesFitness struct {
 	Samples int
 }
 
+func (f TripsAndSamplesFitness) String() string {
+	return fmt.Sprintf("%d trips, %d samples", f.Trips, f.Samples)
+}
+
+// TripsAndSamplesEvaluator counts trips and own samples.
+//
+// By default, each visit of a site at a time step counts as a trip.
+// With CombineSites, all sites visited at the same time step are combined into a single trip.
+//
+// TripsAndSamplesEvaluator is an [isso.IncrementalEvaluator] and an [isso.Cloner].
 type TripsAndSamplesEvaluator struct {
-	times []int
+	CombineSites bool
+	times        [][]int
+
+	// State for incremental evaluation.
+	counts  [][]int
+	stack   []isso.ActionDef
+	trips   int
+	samples int
+}
+
+// Clone returns a new evaluator with the same settings, and without evaluation state.
+func (e *TripsAndSamplesEvaluator) Clone() isso.Evaluator[TripsAndSamplesFitness] {
+	return &TripsAndSamplesEvaluator{CombineSites: e.CombineSites}
 }
 
 func (e *TripsAndSamplesEvaluator) Evaluate(sol []isso.ActionDef) TripsAndSamplesFitness {
 	for i := range e.times {
-		e.times[i] = 0
+		for j := range e.times[i] {
+			e.times[i][j] = 0
+		}
 	}
 	samples := 0
 	for _, a := range sol {
-		for len(e.times) <= a.Time {
-			e.times = append(e.times, 0)
+		site := int(a.Site)
+		if e.CombineSites {
+			site = 0
+		}
+		for len(e.times) <= site {
+			e.times = append(e.times, []int{})
 		}
-		e.times[a.Time] = 1
+		for len(e.times[site]) <= a.Time {
+			e.times[site] = append(e.times[site], 0)
+		}
+		e.times[site][a.Time] = 1
 		if a.Reuse < 0 {
 			samples += a.Samples
 		}
 	}
 	trips := 0
-	for _, t := range e.times {
-		trips += t
+	for _, times := range e.times {
+		for _, t := range times {
+			trips += t
+		}
 	}
 
 	return TripsAndSamplesFitness{
@@ -55,6 +89,16 @@ func (e *TripsThenSamples) Compare(a, b TripsAndSamplesFitness) int {
 	return 1
 }
 
+func (e *TripsThenSamples) Equal(a, b TripsAndSamplesFitness) bool {
+	return a == b
+}
+
+// Within checks whether trips and samples of a are both within the tolerance of those of b.
+func (e *TripsThenSamples) Within(a, b TripsAndSamplesFitness, tolerance float64) bool {
+	return within(float64(a.Trips), float64(b.Trips), tolerance) &&
+		within(float64(a.Samples), float64(b.Samples), tolerance)
+}
+
 func (e *TripsThenSamples) IsPareto() bool {
 	return false
 }
@@ -80,6 +124,70 @@ func (e *TripsSamplesPareto) Compare(a, b TripsAndSamplesFitness) int {
 	return 0
 }
 
+func (e *TripsSamplesPareto) Equal(a, b TripsAndSamplesFitness) bool {
+	return a == b
+}
+
 func (e *TripsSamplesPareto) IsPareto() bool {
 	return true
 }
+
+// Reset resets the incremental evaluation to the given actions.
+func (e *TripsAndSamplesEvaluator) Reset(actions []isso.ActionDef) {
+	for i := range e.counts {
+		clear(e.counts[i])
+	}
+	e.stack = e.stack[:0]
+	e.trips, e.samples = 0, 0
+	for _, a := range actions {
+		e.Push(a)
+	}
+}
+
+// Push adds an action to the incremental evaluation.
+func (e *TripsAndSamplesEvaluator) Push(a isso.ActionDef) {
+	site := int(a.Site)
+	if e.CombineSites {
+		site = 0
+	}
+	for len(e.counts) <= site {
+		e.counts = append(e.counts, []int{})
+	}
+	for len(e.counts[site]) <= a.Time {
+		e.counts[site] = append(e.counts[site], 0)
+	}
+	if e.counts[site][a.Time] == 0 {
+		e.trips++
+	}
+	e.counts[site][a.Time]++
+	if a.Reuse < 0 {
+		e.samples += a.Samples
+	}
+	e.stack = append(e.stack, a)
+}
+
+// Pop removes the last pushed action from the incremental evaluation.
+func (e *TripsAndSamplesEvaluator) Pop() {
+	a := e.stack[len(e.stack)-1]
+	e.stack = e.stack[:len(e.stack)-1]
+
+	site := int(a.Site)
+	if e.CombineSites {
+		site = 0
+	}
+	e.counts[site][a.Time]--
+	if e.counts[site][a.Time] == 0 {
+		e.trips--
+	}
+	if a.Reuse < 0 {
+		e.samples -= a.Samples
+	}
+}
+
+// Fitness returns the fitness of the incremental evaluation's current actions.
+func (e *TripsAndSamplesEvaluator) Fitness() TripsAndSamplesFitness {
+	return TripsAndSamplesFitness{
+		e.trips,
+		e.samples,
+	}
+}
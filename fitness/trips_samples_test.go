@@ -1,6 +1,9 @@
 package fitness_test
 
 import (
+	"encoding/json"
+	"math/rand"
+	"os"
 	"testing"
 
 	"github.com/mlange-42/isso"
@@ -38,6 +41,31 @@ func TestTripsAndSamplesEvaluator(t *testing.T) {
 	assert.Equal(t, f{Trips: 2, Samples: 150}, fit)
 }
 
+func TestTripsAndSamplesEvaluatorSites(t *testing.T) {
+	solution := []isso.ActionDef{
+		{
+			Subject: 1,
+			Samples: 100,
+			Site:    0,
+			Time:    2,
+			Reuse:   -1,
+		},
+		{
+			Subject: 2,
+			Samples: 50,
+			Site:    1,
+			Time:    2,
+			Reuse:   -1,
+		},
+	}
+
+	eval := fitness.TripsAndSamplesEvaluator{}
+	assert.Equal(t, f{Trips: 2, Samples: 150}, eval.Evaluate(solution))
+
+	eval = fitness.TripsAndSamplesEvaluator{CombineSites: true}
+	assert.Equal(t, f{Trips: 1, Samples: 150}, eval.Evaluate(solution))
+}
+
 func TestTripsThenSamples(t *testing.T) {
 	comp := fitness.TripsThenSamples{}
 
@@ -69,6 +97,11 @@ func TestTripsThenSamples(t *testing.T) {
 		f{Trips: 1, Samples: 101},
 		f{Trips: 1, Samples: 100},
 	))
+
+	assert.True(t, comp.Within(f{Trips: 11, Samples: 110}, f{Trips: 10, Samples: 100}, 0.1))
+	assert.True(t, comp.Within(f{Trips: 9, Samples: 90}, f{Trips: 10, Samples: 100}, 0.1))
+	assert.False(t, comp.Within(f{Trips: 12, Samples: 100}, f{Trips: 10, Samples: 100}, 0.1))
+	assert.False(t, comp.Within(f{Trips: 10, Samples: 111}, f{Trips: 10, Samples: 100}, 0.1))
 }
 
 func TestTripsSamplesPareto(t *testing.T) {
@@ -119,3 +152,77 @@ func TestTripsSamplesPareto(t *testing.T) {
 		f{Trips: 1, Samples: 100},
 	))
 }
+
+func TestTripsAndSamplesEvaluatorIncremental(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+
+	for _, combine := range []bool{false, true} {
+		inc := fitness.TripsAndSamplesEvaluator{CombineSites: combine}
+		full := fitness.TripsAndSamplesEvaluator{CombineSites: combine}
+
+		fixed := []isso.ActionDef{
+			{Subject: 0, Site: 1, Time: 2, Samples: 100, Reuse: -1},
+		}
+		actions := append([]isso.ActionDef{}, fixed...)
+		inc.Reset(fixed)
+		assert.Equal(t, full.Evaluate(actions), inc.Fitness())
+
+		for i := 0; i < 1000; i++ {
+			if len(actions) > len(fixed) && rng.Float64() < 0.45 {
+				actions = actions[:len(actions)-1]
+				inc.Pop()
+			} else {
+				reuse := isso.NoSubject
+				if rng.Float64() < 0.3 {
+					reuse = 0
+				}
+				a := isso.NewActionDef(isso.SubjectID(rng.Intn(5)), 0, isso.SiteID(rng.Intn(3)), rng.Intn(8), rng.Intn(100), reuse)
+				actions = append(actions, a)
+				inc.Push(a)
+			}
+			assert.Equal(t, full.Evaluate(actions), inc.Fitness())
+		}
+
+		inc.Reset(fixed)
+		assert.Equal(t, f{Trips: 1, Samples: 100}, inc.Fitness())
+	}
+}
+
+// fullEvaluator hides incremental evaluation from the solver.
+type fullEvaluator struct {
+	eval fitness.TripsAndSamplesEvaluator
+}
+
+func (e *fullEvaluator) Evaluate(sol []isso.ActionDef) f {
+	return e.eval.Evaluate(sol)
+}
+
+func benchmarkProblem(b *testing.B) isso.Problem {
+	data, err := os.ReadFile("../data/problem.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	def := isso.ProblemDef{}
+	if err := json.Unmarshal(data, &def); err != nil {
+		b.Fatal(err)
+	}
+	return isso.NewProblem(def)
+}
+
+func BenchmarkSolveIncremental(b *testing.B) {
+	p := benchmarkProblem(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := isso.NewSolver[f](&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{})
+		s.Solve(&p)
+	}
+}
+
+func BenchmarkSolveFull(b *testing.B) {
+	p := benchmarkProblem(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := isso.NewSolver[f](&fullEvaluator{}, &fitness.TripsThenSamples{})
+		s.Solve(&p)
+	}
+}
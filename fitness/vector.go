@@ -0,0 +1,142 @@
+package fitness
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mlange-42/isso"
+)
+
+// Vector is a fitness with an arbitrary number of objectives. Lower values are better.
+type Vector []float64
+
+func (f Vector) String() string {
+	parts := make([]string, len(f))
+	for i, v := range f {
+		parts[i] = fmt.Sprintf("%g", v)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// VectorEvaluator derives a fitness vector from the fitness of another evaluator,
+// using one key per objective.
+//
+// VectorEvaluator is an [isso.Cloner], and clones the wrapped evaluator if it is one.
+// It is [isso.ProblemAware] and an [isso.IncrementalEvaluator], and forwards to the wrapped evaluator
+// if it implements these interfaces. Otherwise, Init does nothing,
+// and incremental fitness is derived by evaluating the current actions.
+type VectorEvaluator[F any] struct {
+	evaluator isso.Evaluator[F]
+	keys      []Key[F]
+	actions   []isso.ActionDef // Current actions, if the wrapped evaluator is not incremental.
+}
+
+// NewVectorEvaluator creates a new VectorEvaluator from an evaluator and keys for the objectives.
+func NewVectorEvaluator[F any](evaluator isso.Evaluator[F], keys ...Key[F]) *VectorEvaluator[F] {
+	return &VectorEvaluator[F]{
+		evaluator: evaluator,
+		keys:      keys,
+	}
+}
+
+// Clone returns a new evaluator with the same keys, and a clone of the wrapped evaluator if it is an [isso.Cloner].
+func (e *VectorEvaluator[F]) Clone() isso.Evaluator[Vector] {
+	evaluator := e.evaluator
+	if c, ok := evaluator.(isso.Cloner[F]); ok {
+		evaluator = c.Clone()
+	}
+	return &VectorEvaluator[F]{
+		evaluator: evaluator,
+		keys:      e.keys,
+	}
+}
+
+// Init initializes the wrapped evaluator for a problem, if it is [isso.ProblemAware].
+func (e *VectorEvaluator[F]) Init(p *isso.Problem) {
+	if pa, ok := e.evaluator.(isso.ProblemAware); ok {
+		pa.Init(p)
+	}
+}
+
+func (e *VectorEvaluator[F]) Evaluate(sol []isso.ActionDef) Vector {
+	return e.vector(e.evaluator.Evaluate(sol))
+}
+
+func (e *VectorEvaluator[F]) Reset(actions []isso.ActionDef) {
+	if inc, ok := e.evaluator.(isso.IncrementalEvaluator[F]); ok {
+		inc.Reset(actions)
+		return
+	}
+	e.actions = append(e.actions[:0], actions...)
+}
+
+func (e *VectorEvaluator[F]) Push(action isso.ActionDef) {
+	if inc, ok := e.evaluator.(isso.IncrementalEvaluator[F]); ok {
+		inc.Push(action)
+		return
+	}
+	e.actions = append(e.actions, action)
+}
+
+func (e *VectorEvaluator[F]) Pop() {
+	if inc, ok := e.evaluator.(isso.IncrementalEvaluator[F]); ok {
+		inc.Pop()
+		return
+	}
+	e.actions = e.actions[:len(e.actions)-1]
+}
+
+func (e *VectorEvaluator[F]) Fitness() Vector {
+	if inc, ok := e.evaluator.(isso.IncrementalEvaluator[F]); ok {
+		return e.vector(inc.Fitness())
+	}
+	return e.vector(e.evaluator.Evaluate(e.actions))
+}
+
+// vector derives the fitness vector from the fitness of the wrapped evaluator.
+func (e *VectorEvaluator[F]) vector(f F) Vector {
+	vec := make(Vector, len(e.keys))
+	for i, k := range e.keys {
+		vec[i] = k(f)
+	}
+	return vec
+}
+
+// VectorPareto is an N-dimensional pareto comparator for fitness vectors.
+// A fitness is better than another if it is not worse in any objective, and better in at least one.
+//
+// An empty vector is treated as the empty incumbent, and any fitness compares better than it.
+type VectorPareto struct{}
+
+func (e *VectorPareto) Compare(a, b Vector) int {
+	if len(b) == 0 {
+		return -1
+	}
+	if len(a) != len(b) {
+		panic(fmt.Sprintf("can't compare fitness vectors of length %d and %d", len(a), len(b)))
+	}
+	better, worse := false, false
+	for i := range a {
+		if a[i] < b[i] {
+			better = true
+		} else if a[i] > b[i] {
+			worse = true
+		}
+	}
+	if better && !worse {
+		return -1
+	}
+	if worse && !better {
+		return 1
+	}
+	return 0
+}
+
+func (e *VectorPareto) Equal(a, b Vector) bool {
+	return slices.Equal(a, b)
+}
+
+func (e *VectorPareto) IsPareto() bool {
+	return true
+}
@@ -0,0 +1,129 @@
+package fitness_test
+
+import (
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVector(t *testing.T) {
+	assert.Equal(t, "[1, 2.5, 300]", fitness.Vector{1, 2.5, 300}.String())
+}
+
+func TestVectorPareto(t *testing.T) {
+	comp := fitness.VectorPareto{}
+	type v = fitness.Vector
+
+	assert.True(t, comp.IsPareto())
+
+	assert.Equal(t, -1, comp.Compare(v{5, 1, 100}, nil))
+	assert.Equal(t, -1, comp.Compare(v{5, 1, 100}, v{5, 1, 200}))
+	assert.Equal(t, -1, comp.Compare(v{4, 1, 100}, v{5, 2, 200}))
+	assert.Equal(t, 0, comp.Compare(v{4, 1, 300}, v{5, 2, 200}))
+	assert.Equal(t, 0, comp.Compare(v{5, 1, 100}, v{5, 1, 100}))
+	assert.Equal(t, 1, comp.Compare(v{5, 2, 100}, v{5, 1, 100}))
+
+	assert.True(t, comp.Equal(v{5, 1, 100}, v{5, 1, 100}))
+	assert.False(t, comp.Equal(v{5, 1, 100}, v{5, 1, 101}))
+
+	assert.Panics(t, func() { comp.Compare(v{1, 2}, v{1, 2, 3}) })
+}
+
+func TestVectorEvaluatorSolve(t *testing.T) {
+	p := isso.NewProblem(isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}},
+		Capacity: []int{100, 200, 100},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Matrix: "fruits", Samples: 100, Times: []int{0, 1}},
+			{Subject: "Pest 2", Matrix: "fruits", Samples: 100, Times: []int{1, 2}},
+		},
+		Costs: &isso.Costs{
+			Trips:   []float64{10, 50, 10},
+			Samples: map[string]float64{"fruits": 1},
+		},
+	})
+
+	eval := fitness.NewVectorEvaluator[fitness.CostFitness](
+		fitness.NewCostEvaluator(&p),
+		func(f fitness.CostFitness) float64 { return float64(f.Trips) },
+		func(f fitness.CostFitness) float64 { return float64(f.Samples) },
+		func(f fitness.CostFitness) float64 { return f.TripCost },
+	)
+	s := isso.NewSolver[fitness.Vector](eval, &fitness.VectorPareto{})
+
+	solution, ok := s.Solve(&p)
+	assert.True(t, ok)
+
+	fit := []fitness.Vector{}
+	for _, sol := range solution {
+		fit = append(fit, sol.Fitness)
+	}
+	assert.ElementsMatch(t, []fitness.Vector{{1, 100, 50}, {2, 200, 20}}, fit)
+}
+
+func TestVectorEvaluatorForwarding(t *testing.T) {
+	def := isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}},
+		Capacity: []int{100, 200, 100},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Matrix: "fruits", Samples: 100, Times: []int{0, 1}},
+			{Subject: "Pest 2", Matrix: "fruits", Samples: 100, Times: []int{1, 2}},
+		},
+		Costs: &isso.Costs{
+			Trips:   []float64{10, 50, 10},
+			Samples: map[string]float64{"fruits": 1},
+		},
+	}
+	p := isso.NewProblem(def)
+	def.Costs = &isso.Costs{
+		Trips:   []float64{10, 5, 10},
+		Samples: map[string]float64{"fruits": 1},
+	}
+	q := isso.NewProblem(def)
+
+	tripCost := func(f fitness.CostFitness) float64 { return f.TripCost }
+	samples := func(f fitness.CostFitness) float64 { return float64(f.Samples) }
+
+	// The wrapped evaluator is initialized by the solver, for each problem.
+	for _, eval := range []*fitness.VectorEvaluator[fitness.CostFitness]{
+		fitness.NewVectorEvaluator[fitness.CostFitness](&fitness.CostEvaluator{}, tripCost, samples),
+		fitness.NewVectorEvaluator[fitness.CostFitness](fitness.NewCostEvaluator(&p), tripCost, samples),
+	} {
+		var _ isso.ProblemAware = eval
+		s := isso.NewSolver[fitness.Vector](eval, &fitness.VectorPareto{})
+
+		solution, ok := s.Solve(&q)
+		assert.True(t, ok)
+		assert.Equal(t, 1, len(solution))
+		assert.Equal(t, fitness.Vector{5, 100}, solution[0].Fitness)
+
+		solution, ok = s.Solve(&p)
+		assert.True(t, ok)
+		assert.Equal(t, 2, len(solution))
+	}
+
+	// Incremental evaluation is forwarded to the wrapped evaluator.
+	inner := fitness.TripsAndSamplesEvaluator{}
+	eval := fitness.NewVectorEvaluator[fitness.TripsAndSamplesFitness](&inner,
+		func(f fitness.TripsAndSamplesFitness) float64 { return float64(f.Trips) },
+		func(f fitness.TripsAndSamplesFitness) float64 { return float64(f.Samples) },
+	)
+	var _ isso.IncrementalEvaluator[fitness.Vector] = eval
+	actions := []isso.ActionDef{isso.NewActionDef(0, 0, 0, 1, 100, isso.NoSubject)}
+	eval.Reset(nil)
+	eval.Push(actions[0])
+	assert.Equal(t, fitness.Vector{1, 100}, eval.Fitness())
+	assert.Equal(t, fitness.TripsAndSamplesFitness{Trips: 1, Samples: 100}, inner.Fitness())
+	eval.Pop()
+	assert.Equal(t, fitness.Vector{0, 0}, eval.Fitness())
+
+	// Without an incremental wrapped evaluator, fitness is derived from the current actions.
+	costs := fitness.NewVectorEvaluator[fitness.CostFitness](fitness.NewCostEvaluator(&p), tripCost, samples)
+	costs.Reset(nil)
+	costs.Push(actions[0])
+	assert.Equal(t, costs.Evaluate(actions), costs.Fitness())
+	costs.Pop()
+	assert.Equal(t, costs.Evaluate(nil), costs.Fitness())
+}
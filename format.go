@@ -0,0 +1,591 @@
+package isso
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format of problem definition files.
+type Format string
+
+// Supported formats of problem definition files.
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+// FormatOf returns the format of a file, from its extension.
+// Files with unknown extensions are treated as JSON.
+func FormatOf(file string) Format {
+	f, err := ParseFormat(strings.TrimPrefix(filepath.Ext(file), "."))
+	if err != nil {
+		return FormatJSON
+	}
+	return f
+}
+
+// ParseFormat parses a format name. One of [json yaml yml toml], case-insensitive.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return FormatJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "toml":
+		return FormatTOML, nil
+	}
+	return "", fmt.Errorf("unknown format '%s'; must be one of [json yaml toml]", name)
+}
+
+// ReadProblem reads a problem definition in the given format.
+//
+// Field names and values are the same in all formats.
+// Errors carry the line and column of the offending value.
+// For TOML, values inside inline arrays and tables are reported at the position of their key.
+func ReadProblem(r io.Reader, format Format) (ProblemDef, error) {
+	problem := ProblemDef{}
+	doc, err := readDocument(r, format)
+	if err != nil {
+		return problem, err
+	}
+	err = doc.decode(&problem)
+	return problem, err
+}
+
+// WriteProblem writes a problem definition in the given format.
+// Null values are omitted for YAML and TOML.
+func WriteProblem(w io.Writer, problem *ProblemDef, format Format) error {
+	data, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	doc, err := readDocument(bytes.NewReader(data), FormatJSON)
+	if err != nil {
+		return err
+	}
+	return doc.write(w, format)
+}
+
+// ConvertProblem translates a problem definition file from one format to another.
+//
+// The problem is validated by decoding it, but written from the original document,
+// so that times given as dates, labels or ranges are preserved.
+// Keys are written in alphabetical order.
+func ConvertProblem(r io.Reader, from Format, w io.Writer, to Format) error {
+	doc, err := readDocument(r, from)
+	if err != nil {
+		return err
+	}
+	if err := doc.decode(&ProblemDef{}); err != nil {
+		return err
+	}
+	return doc.write(w, to)
+}
+
+// document is a problem definition file, decoded to generic values.
+type document struct {
+	value  any                     // Decoded document, as maps, slices and scalars.
+	json   []byte                  // The document as JSON, for decoding.
+	locate func(path []any) string // Source position of the value at a path of object keys and array indices.
+}
+
+// readDocument reads a document in the given format.
+func readDocument(r io.Reader, format Format) (*document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	doc := document{}
+	switch format {
+	case FormatJSON:
+		doc.value, err = readJSON(data)
+		doc.json = data
+		doc.locate = func(path []any) string { return jsonLocate(data, path) }
+	case FormatYAML:
+		root := yaml.Node{}
+		doc.value, err = readYAML(data, &root)
+		doc.locate = func(path []any) string { return yamlLocate(&root, path) }
+	case FormatTOML:
+		doc.value, err = readTOML(data)
+		doc.locate = func(path []any) string { return tomlLocate(data, path) }
+	default:
+		return nil, fmt.Errorf("unknown format '%s'; must be one of [json yaml toml]", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if doc.json == nil {
+		if doc.json, err = json.Marshal(doc.value); err != nil {
+			return nil, err
+		}
+	}
+	return &doc, nil
+}
+
+// decode decodes the document into a value, using JSON decoding.
+// Type errors and errors in time entries are reported with the source position of the offending value.
+func (d *document) decode(v any) error {
+	err := json.Unmarshal(d.json, v)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		pos := d.locate(jsonPath(d.json, int(typeErr.Offset)))
+		return fmt.Errorf("%s: cannot use %s as %s value of %s", pos, typeErr.Value, typeErr.Type, typeErr.Field)
+	}
+	var timeErr *timeError
+	if errors.As(err, &timeErr) {
+		return fmt.Errorf("%s: %s", d.locate(timeErr.path), timeErr.Error())
+	}
+	return err
+}
+
+// write writes the document in the given format.
+func (d *document) write(w io.Writer, format Format) error {
+	value := plain(d.value)
+	b := bytes.Buffer{}
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "    ")
+		if err := enc.Encode(d.value); err != nil {
+			return err
+		}
+	case FormatYAML:
+		y := yaml.Node{}
+		if err := y.Encode(value); err != nil {
+			return err
+		}
+		flowScalars(&y)
+		enc := yaml.NewEncoder(&b)
+		enc.SetIndent(2)
+		if err := enc.Encode(&y); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	case FormatTOML:
+		if _, ok := value.(map[string]any); !ok {
+			return fmt.Errorf("TOML documents must be tables")
+		}
+		enc := toml.NewEncoder(&b)
+		enc.Indent = ""
+		if err := enc.Encode(value); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format '%s'; must be one of [json yaml toml]", format)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// plain prepares a decoded value for the YAML and TOML encoders.
+// Null values of objects are removed, and numbers are converted to int64 or float64.
+func plain(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			if e != nil {
+				m[k] = plain(e)
+			}
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = plain(e)
+		}
+		return s
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// flowScalars sets the flow style for YAML sequences of scalars, like lists of times.
+func flowScalars(y *yaml.Node) {
+	if y.Kind == yaml.SequenceNode {
+		y.Style = yaml.FlowStyle
+	}
+	for _, c := range y.Content {
+		if c.Kind != yaml.ScalarNode {
+			y.Style = 0
+		}
+		flowScalars(c)
+	}
+}
+
+// readJSON reads a JSON document.
+func readJSON(data []byte) (any, error) {
+	var value any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err := dec.Decode(&value)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return nil, fmt.Errorf("%s: %s", jsonPosition(data, int(syntaxErr.Offset)-1), syntaxErr.Error())
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: unexpected end of JSON input", jsonPosition(data, len(data)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("%s: invalid data after top-level value", jsonPosition(data, int(dec.InputOffset())))
+	}
+	return value, nil
+}
+
+// jsonPosition returns the line and column of a byte offset.
+func jsonPosition(data []byte, offset int) string {
+	offset = min(max(offset, 0), len(data))
+	line := bytes.Count(data[:offset], []byte{'\n'}) + 1
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return fmt.Sprintf("line %d, column %d", line, column)
+}
+
+// jsonValue is a value of a JSON document, with its path and byte range.
+type jsonValue struct {
+	path       []any
+	start, end int
+}
+
+// jsonValues lists the values of a valid JSON document, parents before their children.
+func jsonValues(data []byte) []jsonValue {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	values := []jsonValue{}
+	var read func(path []any) bool
+	read = func(path []any) bool {
+		start := int(dec.InputOffset())
+		for start < len(data) && strings.IndexByte(" \t\r\n,:", data[start]) >= 0 {
+			start++
+		}
+		idx := len(values)
+		values = append(values, jsonValue{path: path, start: start})
+
+		tok, err := dec.Token()
+		if err != nil {
+			return false
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			for i := 0; dec.More(); i++ {
+				var key any = i
+				if delim == '{' {
+					if key, err = dec.Token(); err != nil {
+						return false
+					}
+				}
+				if !read(append(slices.Clip(path), key)) {
+					return false
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				return false
+			}
+		}
+		values[idx].end = int(dec.InputOffset())
+		return true
+	}
+	read([]any{})
+	return values
+}
+
+// jsonPath returns the path of the innermost value that contains a byte offset.
+func jsonPath(data []byte, offset int) []any {
+	path := []any{}
+	for _, v := range jsonValues(data) {
+		if v.start < offset && offset <= v.end {
+			path = v.path
+		}
+	}
+	return path
+}
+
+// jsonLocate returns the position of the value at a path, or of its innermost existing parent.
+func jsonLocate(data []byte, path []any) string {
+	best := jsonValue{}
+	for _, v := range jsonValues(data) {
+		if len(v.path) > len(best.path) && matchPath(v.path, path) {
+			best = v
+		}
+	}
+	return jsonPosition(data, best.start)
+}
+
+// matchPath checks whether a path is a prefix of another path.
+// Keys are matched case-insensitively, like in JSON decoding.
+func matchPath(prefix, path []any) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i, p := range prefix {
+		if s, ok := p.(string); ok {
+			if t, ok := path[i].(string); !ok || !strings.EqualFold(s, t) {
+				return false
+			}
+		} else if p != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// readYAML reads a YAML document, and keeps its node tree for locating errors.
+func readYAML(data []byte, root *yaml.Node) (any, error) {
+	if err := yaml.Unmarshal(data, root); err != nil {
+		return nil, errors.New(strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	// Timestamps are kept as written, like dates in JSON.
+	var retag func(y *yaml.Node)
+	retag = func(y *yaml.Node) {
+		if y.Kind == yaml.ScalarNode && y.ShortTag() == "!!timestamp" {
+			y.Tag = "!!str"
+		}
+		for _, c := range y.Content {
+			retag(c)
+		}
+	}
+	retag(root)
+
+	value := map[string]any{}
+	if len(root.Content) == 0 {
+		return value, nil
+	}
+	if err := root.Decode(&value); err != nil {
+		return nil, errors.New(strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	return value, nil
+}
+
+// yamlLocate returns the position of the value at a path, or of its innermost existing parent.
+// Values from merge keys are located in the merged mapping.
+func yamlLocate(root *yaml.Node, path []any) string {
+	y := root
+	for len(path) > 0 {
+		for y.Kind == yaml.DocumentNode || y.Kind == yaml.AliasNode {
+			if y.Kind == yaml.AliasNode {
+				y = y.Alias
+			} else if len(y.Content) > 0 {
+				y = y.Content[0]
+			} else {
+				break
+			}
+		}
+		next := yamlChild(y, path[0])
+		if next == nil {
+			break
+		}
+		y, path = next, path[1:]
+	}
+	return fmt.Sprintf("line %d, column %d", max(y.Line, 1), max(y.Column, 1))
+}
+
+// yamlChild returns the value of a mapping key or sequence index, or nil if there is none.
+func yamlChild(y *yaml.Node, key any) *yaml.Node {
+	switch key := key.(type) {
+	case int:
+		if y.Kind == yaml.SequenceNode && key < len(y.Content) {
+			return y.Content[key]
+		}
+	case string:
+		if y.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(y.Content); i += 2 {
+			if strings.EqualFold(y.Content[i].Value, key) {
+				return y.Content[i+1]
+			}
+		}
+		for i := 0; i+1 < len(y.Content); i += 2 {
+			if y.Content[i].Value != "<<" {
+				continue
+			}
+			merged := []*yaml.Node{y.Content[i+1]}
+			if merged[0].Kind == yaml.SequenceNode {
+				merged = merged[0].Content
+			}
+			for _, m := range merged {
+				if m.Kind == yaml.AliasNode {
+					m = m.Alias
+				}
+				if c := yamlChild(m, key); c != nil {
+					return c
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readTOML reads a TOML document.
+func readTOML(data []byte) (any, error) {
+	value := map[string]any{}
+	if _, err := toml.Decode(string(data), &value); err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("line %d, column %d: %s", parseErr.Position.Line, parseErr.Position.Col, parseErr.Message)
+		}
+		return nil, err
+	}
+	return tomlDates(value), nil
+}
+
+// tomlDates converts TOML dates and times to strings, like dates in JSON.
+func tomlDates(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = tomlDates(e)
+		}
+	case []map[string]any:
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = tomlDates(e)
+		}
+		return s
+	case []any:
+		for i, e := range v {
+			v[i] = tomlDates(e)
+		}
+	case time.Time:
+		switch v.Location().String() {
+		case "date-local":
+			return v.Format(DateFormat)
+		case "datetime-local":
+			return v.Format("2006-01-02T15:04:05.999999999")
+		case "time-local":
+			return v.Format("15:04:05.999999999")
+		}
+		return v.Format(time.RFC3339Nano)
+	}
+	return value
+}
+
+// tomlLocate returns the position of the value at a path, or of its innermost existing parent.
+//
+// Lines are scanned for table headers and keys, counting the elements of arrays of tables.
+// Values inside inline arrays and tables are located at their key.
+func tomlLocate(data []byte, path []any) string {
+	best, bestLen := "line 1, column 1", 0
+	table := []any{}
+	tables := map[string]int{} // Number of elements of arrays of tables.
+	inString := false          // Inside a multi-line string.
+
+	for i, line := range strings.SplitAfter(string(data), "\n") {
+		wasString := inString
+		if strings.Count(line, `"""`)%2 == 1 || strings.Count(line, `'''`)%2 == 1 {
+			inString = !inString
+		}
+		if wasString {
+			continue
+		}
+		text := strings.TrimLeft(line, " \t")
+		column := len(line) - len(text) + 1
+
+		var current []any
+		if strings.HasPrefix(text, "[") {
+			array := strings.HasPrefix(text, "[[")
+			keys, _, ok := tomlKeys(strings.TrimLeft(text, "["))
+			if !ok {
+				continue
+			}
+			// Headers of nested tables refer to the last element of arrays of tables.
+			table = []any{}
+			for j, k := range keys {
+				table = append(table, k)
+				if n, ok := tables[fmt.Sprintf("%q", table)]; ok && (j < len(keys)-1 || !array) {
+					table = append(table, n-1)
+				}
+			}
+			if array {
+				name := fmt.Sprintf("%q", table)
+				table = append(table, tables[name])
+				tables[name]++
+			}
+			current = table
+		} else {
+			keys, rest, ok := tomlKeys(text)
+			if !ok || !strings.HasPrefix(rest, "=") {
+				continue
+			}
+			current = append(slices.Clip(table), keys...)
+			rest = strings.TrimLeft(rest[1:], " \t")
+			column = len(line) - len(rest) + 1
+		}
+		if len(current) > bestLen && matchPath(current, path) {
+			best, bestLen = fmt.Sprintf("line %d, column %d", i+1, column), len(current)
+		}
+	}
+	return best
+}
+
+// tomlKeys parses a dotted TOML key at the start of a text.
+// Returns the keys, and the rest of the text after the key and following whitespace.
+func tomlKeys(text string) ([]any, string, bool) {
+	keys := []any{}
+	for {
+		text = strings.TrimLeft(text, " \t")
+		var key string
+		switch {
+		case strings.HasPrefix(text, `"`):
+			end := 1
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(text) {
+				return nil, "", false
+			}
+			k, err := strconv.Unquote(text[:end+1])
+			if err != nil {
+				return nil, "", false
+			}
+			key, text = k, text[end+1:]
+		case strings.HasPrefix(text, "'"):
+			end := strings.IndexByte(text[1:], '\'')
+			if end < 0 {
+				return nil, "", false
+			}
+			key, text = text[1:end+1], text[end+2:]
+		default:
+			end := strings.IndexFunc(text, func(r rune) bool {
+				return !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+			})
+			if end < 0 {
+				end = len(text)
+			}
+			if end == 0 {
+				return nil, "", false
+			}
+			key, text = text[:end], text[end:]
+		}
+		keys = append(keys, key)
+		text = strings.TrimLeft(text, " \t")
+		if !strings.HasPrefix(text, ".") {
+			return keys, text, true
+		}
+		text = text[1:]
+	}
+}
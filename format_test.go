@@ -0,0 +1,174 @@
+package isso_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatOf(t *testing.T) {
+	assert.Equal(t, isso.FormatJSON, isso.FormatOf("data/problem.json"))
+	assert.Equal(t, isso.FormatYAML, isso.FormatOf("data/problem.yaml"))
+	assert.Equal(t, isso.FormatYAML, isso.FormatOf("data/problem.YML"))
+	assert.Equal(t, isso.FormatTOML, isso.FormatOf("data/problem.toml"))
+	assert.Equal(t, isso.FormatJSON, isso.FormatOf("data/problem.txt"))
+
+	_, err := isso.ParseFormat("xml")
+	assert.NotNil(t, err)
+}
+
+func TestReadProblemFormats(t *testing.T) {
+	expected := readProblemFile(t, "data/timeline.json")
+
+	for _, file := range []string{"data/timeline.yaml", "data/timeline.toml"} {
+		problem := readProblemFile(t, file)
+		assert.Equal(t, expected, problem, file)
+	}
+}
+
+func TestReadProblemYAMLMerge(t *testing.T) {
+	input := `Defaults: &pest
+  Matrix: fruits
+  Times: [0, 1]
+  Samples: 2
+Matrices:
+  - Name: fruits
+Capacity: [5, 5]
+Requirements:
+  - <<: *pest
+    Subject: Pest 1
+  - <<: *pest
+    Subject: Pest 2
+    Samples: 3
+`
+	problem, err := isso.ReadProblem(strings.NewReader(input), isso.FormatYAML)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(problem.Requirements))
+	assert.Equal(t, isso.Requirement{Subject: "Pest 1", Matrix: "fruits", Times: []int{0, 1}, Samples: 2}, problem.Requirements[0])
+	assert.Equal(t, isso.Requirement{Subject: "Pest 2", Matrix: "fruits", Times: []int{0, 1}, Samples: 3}, problem.Requirements[1])
+}
+
+func TestConvertProblem(t *testing.T) {
+	files, err := filepath.Glob("data/*.json")
+	assert.Nil(t, err)
+	formats := []isso.Format{isso.FormatJSON, isso.FormatYAML, isso.FormatTOML}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		assert.Nil(t, err)
+		expected, err := isso.ReadProblem(bytes.NewReader(data), isso.FormatJSON)
+		assert.Nil(t, err)
+
+		for _, format := range formats {
+			b := bytes.Buffer{}
+			assert.Nil(t, isso.ConvertProblem(bytes.NewReader(data), isso.FormatJSON, &b, format), file)
+			problem, err := isso.ReadProblem(bytes.NewReader(b.Bytes()), format)
+			assert.Nil(t, err, file)
+			assert.Equal(t, expected, problem, "%s as %s", file, format)
+
+			b2 := bytes.Buffer{}
+			assert.Nil(t, isso.WriteProblem(&b2, &problem, format), file)
+			problem, err = isso.ReadProblem(bytes.NewReader(b2.Bytes()), format)
+			assert.Nil(t, err, file)
+			assert.Equal(t, expected, problem, "%s as %s", file, format)
+		}
+	}
+
+	b := bytes.Buffer{}
+	f, err := os.Open("data/timeline.json")
+	assert.Nil(t, err)
+	defer f.Close()
+	assert.Nil(t, isso.ConvertProblem(f, isso.FormatJSON, &b, isso.FormatYAML))
+	assert.Contains(t, b.String(), "Times: [2024-03-18..2024-04-08]\n")
+	assert.Contains(t, b.String(), "Start: \"2024-03-04\"\n")
+	assert.True(t, strings.HasPrefix(b.String(), "Capacity: [150, 250, "))
+
+	b.Reset()
+	js := `{"Matrices": [{"Name": "a \"b\""}], "Capacity": [1], "Sites": null, "Costs": {"Trip": 1.0, "Samples": {"a \"b\"": 0.5}},
+	"Requirements": [{"Subject": "x", "Matrix": "a \"b\"", "Times": [0], "Samples": 1}]}`
+	assert.Nil(t, isso.ConvertProblem(strings.NewReader(js), isso.FormatJSON, &b, isso.FormatTOML))
+	assert.Equal(t, `Capacity = [1]
+
+[Costs]
+Trip = 1.0
+[Costs.Samples]
+"a \"b\"" = 0.5
+
+[[Matrices]]
+Name = "a \"b\""
+
+[[Requirements]]
+Matrix = "a \"b\""
+Samples = 1
+Subject = "x"
+Times = [0]
+`, b.String())
+}
+
+func TestReadProblemErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format isso.Format
+		input  string
+		err    string
+	}{
+		{"json syntax", isso.FormatJSON, "{\n  \"Capacity\": [1, 2,]\n}", "line 2, column 21: invalid character ']'"},
+		{"json eof", isso.FormatJSON, "{\n  \"Capacity\": [1, 2", "line 2, column 20: unexpected end of JSON input"},
+		{"json type", isso.FormatJSON, "{\n  \"Capacity\": [1, \"2\"]\n}",
+			"line 2, column 19: cannot use string as int value of Capacity"},
+		{"yaml syntax", isso.FormatYAML, "Capacity: [1, 2\nMatrices: []", "line 1: "},
+		{"yaml type", isso.FormatYAML, "Requirements:\n  - Subject: Pest 1\n    Samples: many\n",
+			"line 3, column 14: cannot use string as int value of Requirements."},
+		{"yaml times", isso.FormatYAML, "Requirements:\n  - Subject: Pest 1\n    Times: [2024-01-01]\n",
+			"line 3, column 13: times of subject 'Pest 1': invalid time '2024-01-01'"},
+		{"yaml date", isso.FormatYAML, "Timeline: {Start: 2024-01-01, Step: week}\nRequirements:\n  - Subject: Pest 3\n    Times: [0, 2024-13-13]\n",
+			"line 4, column 16: times of subject 'Pest 3': invalid time '2024-13-13'"},
+		{"json times", isso.FormatJSON, "{\"Capacity\": [1, 1],\n \"Requirements\": [{\"Subject\": \"A\", \"times\": [1, 2]}]}",
+			"line 2, column 49: times of subject 'A': time 2 is beyond the last time step 1"},
+		{"toml times", isso.FormatTOML, "[[Constraints]]\nType = \"x\"\nTimes = [-1]\n",
+			"line 3, column 9: times of x constraint 0: negative time '-1'"},
+		{"yaml merge", isso.FormatYAML, "Defaults: &pest\n  Samples: many\nRequirements:\n  - <<: *pest\n    Subject: Pest 1\n",
+			"line 2, column 12: cannot use string as int value of Requirements."},
+		{"toml nested", isso.FormatTOML, "[[Requirements]]\nSubject = \"A\"\n\n[[Requirements]]\nSubject = \"B\"\n\n[Timeline]\nStart = 2024-01-01\nStep = 7\n",
+			"line 9, column 8: cannot use number as string value of Timeline.Step"},
+		{"toml syntax", isso.FormatTOML, "Capacity = [1, 2]\nMatrices = [\n", "line 2, column 13: "},
+		{"toml type", isso.FormatTOML, "[[Requirements]]\nSubject = \"A\"\n\n[[Requirements]]\nSubject = \"B\"\nSamples = \"many\"\n",
+			"line 6, column 11: cannot use string as int value of Requirements."},
+	}
+
+	for _, tt := range tests {
+		_, err := isso.ReadProblem(strings.NewReader(tt.input), tt.format)
+		if assert.NotNil(t, err, tt.name) {
+			assert.True(t, strings.HasPrefix(err.Error(), tt.err), "%s: %s", tt.name, err.Error())
+		}
+	}
+}
+
+func TestReadProblemJSONCompatible(t *testing.T) {
+	files, err := filepath.Glob("data/*.json")
+	assert.Nil(t, err)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		assert.Nil(t, err)
+		expected := isso.ProblemDef{}
+		assert.Nil(t, json.Unmarshal(data, &expected))
+		assert.Equal(t, expected, readProblemFile(t, file), file)
+	}
+}
+
+func readProblemFile(t *testing.T, file string) isso.ProblemDef {
+	f, err := os.Open(file)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	problem, err := isso.ReadProblem(f, isso.FormatOf(file))
+	assert.Nil(t, err, file)
+	return problem
+}
@@ -0,0 +1,82 @@
+package isso
+
+import (
+	"cmp"
+	"slices"
+)
+
+// greedy constructs a solution by a single dive through the search tree, without backtracking.
+//
+// Requirements are selected by the solver's branching strategy. For each requirement, the time
+// at which the site is already visited and with the most free capacity is taken.
+// A found solution is accepted like in the exact search, which seeds the incumbent fitness.
+// Search state is restored on return, and the transposition table is not used.
+//
+// Returns whether a solution was found. The greedy dive fails on dead ends, e.g. due to constraints.
+func (s *search[F]) greedy(sol *actions) bool {
+	r := s.branching.Select(&s.state)
+	if r < 0 {
+		if !s.feasible() {
+			return false
+		}
+		var fitness F
+		if s.incremental != nil {
+			fitness = s.incremental.Fitness()
+		} else {
+			fitness = s.evaluator.Evaluate(sol.Actions)
+		}
+		s.accept(fitness, func() solution[F] {
+			return solution[F]{
+				Actions:   s.coverage.Actions(nil),
+				Decisions: slices.Clone(sol.Actions),
+			}
+		})
+		return true
+	}
+
+	req := &s.problem.requirements[r]
+	siteCapacity := s.coverage.capacity[req.Site]
+	times := slices.Clone(req.Times)
+	slices.SortStableFunc(times, func(a, b int) int {
+		visitedA, visitedB := min(s.state.Visits(req.Site, a), 1), min(s.state.Visits(req.Site, b), 1)
+		if visitedA != visitedB {
+			return cmp.Compare(visitedB, visitedA)
+		}
+		return cmp.Compare(siteCapacity[b], siteCapacity[a])
+	})
+
+	for _, t := range times {
+		if siteCapacity[t] <= 0 {
+			continue
+		}
+		s.candidate = ActionDef{
+			Subject:       req.Subject,
+			Matrix:        req.Matrix,
+			Site:          req.Site,
+			Samples:       min(s.coverage.remaining[r], siteCapacity[t]),
+			TargetSamples: req.Samples,
+			Time:          t,
+			Reuse:         NoSubject,
+		}
+		if !s.allow(&s.candidate) {
+			continue
+		}
+
+		sol.Actions = append(sol.Actions, s.candidate)
+		action := &sol.Actions[len(sol.Actions)-1]
+		s.coverage.Push(action, false)
+		if s.incremental != nil {
+			s.incremental.Push(*action)
+		}
+
+		found := s.greedy(sol)
+
+		if s.incremental != nil {
+			s.incremental.Pop()
+		}
+		s.coverage.Pop(&sol.Actions[len(sol.Actions)-1])
+		sol.Actions = sol.Actions[:len(sol.Actions)-1]
+		return found
+	}
+	return false
+}
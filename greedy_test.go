@@ -0,0 +1,132 @@
+package isso_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+// solveAnytime solves a problem in anytime mode, and collects the reported improvements.
+func solveAnytime[F any](s *isso.Solver[F], p *isso.Problem) ([]isso.Solution[F], bool, []isso.Solution[F]) {
+	improvements := make(chan isso.Solution[F])
+	received := make(chan []isso.Solution[F])
+	go func() {
+		all := []isso.Solution[F]{}
+		for sol := range improvements {
+			all = append(all, sol)
+		}
+		received <- all
+	}()
+	solutions, ok := s.SolveAnytime(p, improvements)
+	return solutions, ok, <-received
+}
+
+func TestSolveAnytime(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		msg := fmt.Sprintf("seed %d", seed)
+		p := isso.NewProblem(generateProblem(seed, 6, 10, 2))
+		comp := &fitness.TripsThenSamples{}
+
+		s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp)
+		expected, ok := s.Solve(&p)
+		nodes := s.Stats().Nodes
+
+		s = isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp)
+		solutions, okAnytime, improvements := solveAnytime(&s, &p)
+
+		assert.Equal(t, ok, okAnytime, msg)
+		assert.Equal(t, expected, solutions, msg)
+		assert.LessOrEqual(t, s.Stats().Nodes, nodes, msg)
+		if !ok {
+			continue
+		}
+
+		assert.Greater(t, len(improvements), 0, msg)
+		for i := 1; i < len(improvements); i++ {
+			assert.Equal(t, -1, comp.Compare(improvements[i].Fitness, improvements[i-1].Fitness), msg)
+		}
+		assert.Equal(t, expected[0].Fitness, improvements[len(improvements)-1].Fitness, msg)
+	}
+}
+
+func TestSolveAnytimePareto(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		msg := fmt.Sprintf("seed %d", seed)
+		p := isso.NewProblem(generateProblem(seed, 6, 10, 2))
+		comp := &fitness.TripsSamplesPareto{}
+
+		s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp)
+		expected, ok := s.Solve(&p)
+
+		s = isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp)
+		solutions, okAnytime, improvements := solveAnytime(&s, &p)
+
+		assert.Equal(t, ok, okAnytime, msg)
+		assert.Equal(t, fitnessSet(expected), fitnessSet(solutions), msg)
+		if ok {
+			assert.GreaterOrEqual(t, len(improvements), len(solutions), msg)
+		}
+	}
+}
+
+func TestSolveAnytimeDecomposed(t *testing.T) {
+	p := isso.NewProblem(generateProblem(1, 6, 16, 2))
+
+	s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{})
+	expected, _ := s.Solve(&p)
+
+	s = isso.NewSolver[fitness.TripsAndSamplesFitness](
+		&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{},
+		isso.WithDecomposition(),
+	)
+	solutions, ok, improvements := solveAnytime(&s, &p)
+	assert.True(t, ok)
+	assert.Greater(t, len(s.Stats().Components), 1)
+	assert.Equal(t, fitnessSet(expected), fitnessSet(solutions))
+	assert.Equal(t, 1, len(improvements))
+	assert.Equal(t, len(expected[0].Actions), len(improvements[0].Actions))
+}
+
+func TestSolveAnytimeArchive(t *testing.T) {
+	comp := &fitness.TripsThenSamples{}
+	for _, tt := range []struct {
+		opts   []isso.Option
+		seeded bool // Whether the greedy solution bounds the search.
+	}{
+		{[]isso.Option{isso.WithTopK(3)}, false},
+		{[]isso.Option{isso.WithTolerance(0.1)}, true},
+		{[]isso.Option{isso.WithTolerance(0.1), isso.WithTopK(3)}, true},
+	} {
+		nodes, nodesAnytime := 0, 0
+		for seed := int64(0); seed < 20; seed++ {
+			msg := fmt.Sprintf("seed %d", seed)
+			p := isso.NewProblem(generateProblem(seed, 6, 10, 2))
+
+			s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp, tt.opts...)
+			expected, ok := s.Solve(&p)
+			nodes += s.Stats().Nodes
+
+			s = isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp, tt.opts...)
+			solutions, okAnytime, improvements := solveAnytime(&s, &p)
+			nodesAnytime += s.Stats().Nodes
+
+			assert.Equal(t, ok, okAnytime, msg)
+			assert.Equal(t, fitnessList(expected), fitnessList(solutions), msg)
+			if !ok {
+				continue
+			}
+			for i := 1; i < len(improvements); i++ {
+				assert.Equal(t, -1, comp.Compare(improvements[i].Fitness, improvements[i-1].Fitness), msg)
+			}
+			assert.Equal(t, expected[0].Fitness, improvements[len(improvements)-1].Fitness, msg)
+		}
+		if tt.seeded {
+			assert.Less(t, nodesAnytime, nodes)
+		} else {
+			assert.Equal(t, nodes, nodesAnytime)
+		}
+	}
+}
@@ -1,12 +1,26 @@
 package isso
 
 import (
+	"fmt"
 	"log"
 	"slices"
+	"sync"
 )
 
-type subject int
-type matrix int
+// SubjectID is the integer ID of a subject, as used in [ActionDef].
+// IDs are the indices of requirements in the problem definition.
+type SubjectID int
+
+// MatrixID is the integer ID of a matrix, as used in [ActionDef].
+// IDs are the indices of matrices in the problem definition.
+type MatrixID int
+
+// SiteID is the integer ID of a site, as used in [ActionDef].
+// IDs are the indices of sites in the problem definition.
+type SiteID int
+
+// NoSubject is the SubjectID used as [ActionDef.Reuse] for actions that take own samples.
+const NoSubject SubjectID = -1
 
 // Requirement definition.
 type Requirement struct {
@@ -14,6 +28,9 @@ type Requirement struct {
 	Matrix  string
 	Times   []int
 	Samples int
+	// Site of the requirement, for problems with multiple sites.
+	// Subject names must be unique over all sites.
+	Site string
 }
 
 // Action definition.
@@ -21,36 +38,63 @@ type Action struct {
 	Subject       string
 	Matrix        string
 	Reuse         string
+	Site          string
 	Time          int
+	Label         string // Label or date of the time step, if the problem has a timeline.
 	Samples       int
 	TargetSamples int
+	Lab           string // Lab the samples are submitted to, for problems with labs.
 }
 
 // requirement for internal use, using no strings.
 type requirement struct {
 	Times   []int
-	Subject subject
-	Matrix  matrix
+	Window  bitset // Times as a bitset.
+	Subject SubjectID
+	Matrix  MatrixID
+	Site    SiteID
 	Samples int
 }
 
 // ActionDef for internal use, using no strings.
 // It needs to be public as it is used in fitness evaluators.
 type ActionDef struct {
-	Subject       subject
-	Matrix        matrix
-	Reuse         subject
+	Subject       SubjectID
+	Matrix        MatrixID
+	Reuse         SubjectID // Subject of the reused samples, or NoSubject for own samples.
+	Site          SiteID
 	Time          int
 	Samples       int
 	TargetSamples int
 }
 
+// NewActionDef creates a new action definition, e.g. for testing fitness evaluators.
+// Use [NoSubject] as reuse for actions that take own samples.
+// Target samples are set to the action's samples.
+func NewActionDef(subject SubjectID, matrix MatrixID, site SiteID, time int, samples int, reuse SubjectID) ActionDef {
+	return ActionDef{
+		Subject:       subject,
+		Matrix:        matrix,
+		Reuse:         reuse,
+		Site:          site,
+		Time:          time,
+		Samples:       samples,
+		TargetSamples: samples,
+	}
+}
+
 // Matrix definition.
 type Matrix struct {
 	Name     string
 	CanReuse []string
 }
 
+// Site definition, for problems with multiple sampling sites.
+type Site struct {
+	Name     string
+	Capacity []int
+}
+
 // Actions of an internal solution.
 type actions struct {
 	Actions []ActionDef
@@ -60,39 +104,127 @@ type actions struct {
 type Solution[F any] struct {
 	Fitness F
 	Actions []Action
+	Tours   []Tour // Tours of all trips, for problems with travel durations.
+	// Lab submission manifest, for problems with labs.
+	// Samples exceeding the capacity of labs are listed as unassigned.
+	Submissions []Submission
 }
 
 // solution for internal use.
 type solution[F any] struct {
-	Fitness F
-	Actions []ActionDef
+	Fitness   F
+	Actions   []ActionDef
+	Decisions []ActionDef // Fixed and chosen actions, as evaluated for the fitness.
+	canonical []ActionDef // Canonical form of the actions, for deduplication.
+	hash      uint64      // Hash of the canonical actions.
 }
 
+// ProblemDef is the definition of a problem, as read from JSON.
 type ProblemDef struct {
 	Matrices     []Matrix
 	Capacity     []int
 	Requirements []Requirement
+	// Optional sampling sites with individual capacity.
+	// Replaces Capacity for problems with multiple sites.
+	// Sites with fewer time steps than others have zero capacity at later time steps.
+	Sites []Site
+	// Actions that were already taken, like samples actually collected in the field.
+	// Only own samples are considered, i.e. actions with an empty Reuse field.
+	// Samples outside the times of their requirement don't cover it, but are part of solutions and can be reused.
+	FixedActions []Action
+	// First time step that is open for planning. Earlier time steps are frozen.
+	CurrentTime int
+	// Optional timeline for mapping time steps to dates or labels.
+	Timeline *Timeline
+	// Optional travel durations between depot and sites.
+	Travel *Travel
+	// Optional labs for analysing samples. Labs are assigned to the actions of solutions after solving.
+	Labs []Lab
+	// Optional prices for cost-based fitness evaluation.
+	Costs *Costs
+	// Optional constraints from the library of common constraints.
+	Constraints []ConstraintDef
 }
 
 // Problem definition.
 type Problem struct {
-	subjectIDs   map[string]subject
-	subjectNames map[subject]string
-	matrixIDs    map[string]matrix
-	matrixNames  map[matrix]string
-	capacity     []int
+	subjectIDs   map[string]SubjectID
+	subjectNames map[SubjectID]string
+	matrixIDs    map[string]MatrixID
+	matrixNames  map[MatrixID]string
+	siteIDs      map[string]SiteID
+	siteNames    map[SiteID]string
+	capacity     [][]int
 	reusable     [][]bool
 	requirements []requirement
+	fixed        []ActionDef
+	timeline     *Timeline
+	travel       *Travel
+	labs         []Lab
+	costs        *CostTable
+	constraints  []Constraint
 }
 
 // NewProblem creates a new problem definition.
 func NewProblem(problem ProblemDef) Problem {
+	sites := problem.Sites
+	if len(sites) == 0 {
+		sites = []Site{{Capacity: problem.Capacity}}
+	} else if len(problem.Capacity) > 0 {
+		log.Fatalf("problem can't have capacity as well as sites; use the capacity of sites instead")
+	}
+
+	siteIDs := map[string]SiteID{}
+	siteNames := map[SiteID]string{}
+	capacity := make([][]int, len(sites))
+	numTimes := 0
+	for i, s := range sites {
+		if _, ok := siteIDs[s.Name]; ok {
+			log.Fatalf("duplicate site '%v'", s.Name)
+		}
+		siteIDs[s.Name] = SiteID(i)
+		siteNames[SiteID(i)] = s.Name
 
-	matrixIDs := map[string]matrix{}
-	matrixNames := map[matrix]string{}
+		capacity[i] = slices.Clone(s.Capacity)
+		for t := 0; t < problem.CurrentTime && t < len(capacity[i]); t++ {
+			capacity[i][t] = 0
+		}
+		numTimes = max(numTimes, len(s.Capacity))
+	}
+	// Sites without capacity given for later time steps have zero capacity there.
+	for i := range capacity {
+		for len(capacity[i]) < numTimes {
+			capacity[i] = append(capacity[i], 0)
+		}
+	}
+
+	var timeline *Timeline
+	if problem.Timeline != nil {
+		if err := problem.Timeline.validate(); err != nil {
+			log.Fatal(err)
+		}
+		if len(problem.Timeline.Labels) > 0 && len(problem.Timeline.Labels) < numTimes {
+			log.Fatalf("timeline has %d labels, but there are %d time steps",
+				len(problem.Timeline.Labels), numTimes)
+		}
+		tl := *problem.Timeline
+		timeline = &tl
+	}
+
+	var travel *Travel
+	if problem.Travel != nil {
+		if err := problem.Travel.validate(len(sites)); err != nil {
+			log.Fatal(err)
+		}
+		tr := *problem.Travel
+		travel = &tr
+	}
+
+	matrixIDs := map[string]MatrixID{}
+	matrixNames := map[MatrixID]string{}
 	for i, m := range problem.Matrices {
-		matrixIDs[m.Name] = matrix(i)
-		matrixNames[matrix(i)] = m.Name
+		matrixIDs[m.Name] = MatrixID(i)
+		matrixNames[MatrixID(i)] = m.Name
 	}
 
 	reusable := make([][]bool, len(problem.Matrices))
@@ -109,13 +241,13 @@ func NewProblem(problem ProblemDef) Problem {
 	}
 
 	req := make([]requirement, len(problem.Requirements))
-	subjectIDs := map[string]subject{}
-	subjectNames := map[subject]string{}
+	subjectIDs := map[string]SubjectID{}
+	subjectNames := map[SubjectID]string{}
 	for i, r := range problem.Requirements {
 		if _, ok := subjectIDs[r.Subject]; ok {
 			log.Fatalf("duplicate subject '%v' in requirements", r.Subject)
 		}
-		sub := subject(i)
+		sub := SubjectID(i)
 
 		subjectIDs[r.Subject] = sub
 		subjectNames[sub] = r.Subject
@@ -124,6 +256,10 @@ func NewProblem(problem ProblemDef) Problem {
 		if !ok {
 			log.Fatalf("unknown matrix '%v'", r.Matrix)
 		}
+		site, ok := siteIDs[r.Site]
+		if !ok {
+			log.Fatalf("unknown site '%v' for subject '%v'", r.Site, r.Subject)
+		}
 
 		times := slices.Clone(r.Times)
 		slices.Sort(times)
@@ -131,62 +267,412 @@ func NewProblem(problem ProblemDef) Problem {
 		if len(times) != len(r.Times) {
 			log.Fatalf("duplicate time entry in times for subject '%v'", r.Subject)
 		}
+		for _, t := range times {
+			if t < 0 || t >= numTimes {
+				log.Fatalf("time %s of subject '%v' is out of range; there are %d time steps",
+					timeline.Label(t), r.Subject, numTimes)
+			}
+		}
 
 		req[i] = requirement{
+			Window:  newBitset(times),
 			Subject: sub,
 			Matrix:  matrix,
+			Site:    site,
 			Samples: r.Samples,
 			Times:   times,
 		}
 	}
 
+	if err := validateLabs(problem.Labs, subjectIDs); err != nil {
+		log.Fatal(err)
+	}
+
+	var costs *CostTable
+	if problem.Costs != nil {
+		var err error
+		costs, err = newCostTable(problem.Costs, problem.Labs, numTimes, matrixIDs, subjectIDs)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	constraints := []Constraint{}
+	for i := range problem.Constraints {
+		c, err := newConstraint(&problem.Constraints[i], subjectIDs, numTimes)
+		if err != nil {
+			log.Fatal(err)
+		}
+		constraints = append(constraints, c)
+	}
+	if travel != nil && travel.MaxDuration > 0 {
+		constraints = append(constraints, maxTourDuration{})
+	}
+
+	fixed := []ActionDef{}
+	for _, a := range problem.FixedActions {
+		if a.Reuse != "" {
+			continue
+		}
+		sub, ok := subjectIDs[a.Subject]
+		if !ok {
+			log.Fatalf("unknown subject '%v' in fixed actions", a.Subject)
+		}
+		r := &req[sub]
+		if a.Matrix != "" && a.Matrix != problem.Requirements[sub].Matrix {
+			log.Fatalf("fixed action for subject '%v' has matrix '%v', but requirement has '%v'",
+				a.Subject, a.Matrix, problem.Requirements[sub].Matrix)
+		}
+		if a.Site != "" && a.Site != problem.Requirements[sub].Site {
+			log.Fatalf("fixed action for subject '%v' has site '%v', but requirement has '%v'",
+				a.Subject, a.Site, problem.Requirements[sub].Site)
+		}
+		siteCapacity := capacity[r.Site]
+		if a.Time < 0 || a.Time >= len(siteCapacity) {
+			log.Fatalf("fixed action for subject '%v' has time %d out of range", a.Subject, a.Time)
+		}
+		if a.Time >= problem.CurrentTime {
+			siteCapacity[a.Time] = max(siteCapacity[a.Time]-a.Samples, 0)
+		}
+		fixed = append(fixed, ActionDef{
+			Subject:       r.Subject,
+			Matrix:        r.Matrix,
+			Site:          r.Site,
+			Samples:       a.Samples,
+			TargetSamples: r.Samples,
+			Time:          a.Time,
+			Reuse:         NoSubject,
+		})
+	}
+
 	return Problem{
 		subjectIDs:   subjectIDs,
 		subjectNames: subjectNames,
 		matrixIDs:    matrixIDs,
 		matrixNames:  matrixNames,
-		capacity:     problem.Capacity,
+		siteIDs:      siteIDs,
+		siteNames:    siteNames,
+		capacity:     capacity,
 		reusable:     reusable,
 		requirements: req,
+		fixed:        fixed,
+		timeline:     timeline,
+		travel:       travel,
+		labs:         slices.Clone(problem.Labs),
+		costs:        costs,
+		constraints:  constraints,
 	}
 }
 
+// SubjectID returns the ID of the subject with the given name.
+func (p *Problem) SubjectID(name string) (SubjectID, bool) {
+	id, ok := p.subjectIDs[name]
+	return id, ok
+}
+
+// MatrixID returns the ID of the matrix with the given name.
+func (p *Problem) MatrixID(name string) (MatrixID, bool) {
+	id, ok := p.matrixIDs[name]
+	return id, ok
+}
+
+// SiteID returns the ID of the site with the given name.
+// The implicit site of problems without sites has the empty name.
+func (p *Problem) SiteID(name string) (SiteID, bool) {
+	id, ok := p.siteIDs[name]
+	return id, ok
+}
+
+// SubjectName returns the name of the subject with the given ID.
+func (p *Problem) SubjectName(id SubjectID) string {
+	return p.subjectNames[id]
+}
+
+// MatrixName returns the name of the matrix with the given ID.
+func (p *Problem) MatrixName(id MatrixID) string {
+	return p.matrixNames[id]
+}
+
+// SiteName returns the name of the site with the given ID.
+// Returns an empty string for the implicit site of problems without sites.
+func (p *Problem) SiteName(id SiteID) string {
+	return p.siteNames[id]
+}
+
+// NumTimes returns the number of time steps of the problem.
+func (p *Problem) NumTimes() int {
+	steps := 0
+	for _, c := range p.capacity {
+		steps = max(steps, len(c))
+	}
+	return steps
+}
+
+// Capacity returns a copy of the sampling capacity, indexed by site ID and time step.
+// It accounts for the current time and for samples of fixed actions.
+func (p *Problem) Capacity() [][]int {
+	capacity := make([][]int, len(p.capacity))
+	for i, c := range p.capacity {
+		capacity[i] = slices.Clone(c)
+	}
+	return capacity
+}
+
+// CanReuse checks whether samples of the source matrix can be used for the given matrix.
+func (p *Problem) CanReuse(m, source MatrixID) bool {
+	return p.reusable[m][source]
+}
+
+// Requirements returns the requirements of the problem, indexed by subject ID.
+func (p *Problem) Requirements() []Requirement {
+	req := make([]Requirement, len(p.requirements))
+	for i := range p.requirements {
+		r := &p.requirements[i]
+		req[i] = Requirement{
+			Subject: p.subjectNames[r.Subject],
+			Matrix:  p.matrixNames[r.Matrix],
+			Site:    p.siteNames[r.Site],
+			Samples: r.Samples,
+			Times:   slices.Clone(r.Times),
+		}
+	}
+	return req
+}
+
+// Timeline returns the timeline of the problem, or nil if there is none.
+func (p *Problem) Timeline() *Timeline {
+	return p.timeline
+}
+
+// ProblemAware is an optional interface for evaluators that require information about the problem.
+// If an evaluator implements it, [Solver.Solve] calls Init before the search starts.
+type ProblemAware interface {
+	Init(p *Problem)
+}
+
+// IncrementalEvaluator is an optional interface for evaluators that can update fitness incrementally.
+// If an evaluator implements it, the solver uses it instead of evaluating the full solution at every step.
+//
+// Reset is called with the problem's fixed actions before the search starts.
+// Push and Pop mirror adding and removing actions during the search.
+// Fitness must return the same value as Evaluate would for the current actions.
+type IncrementalEvaluator[F any] interface {
+	Evaluator[F]
+	Reset(actions []ActionDef)
+	Push(action ActionDef)
+	Pop()
+	Fitness() F
+}
+
 // Comparator interface or comparing fitness values.
+//
+// Equal checks fitness values for equality. It is used instead of the == operator,
+// so that fitness types are not required to be comparable, like slices for fitness vectors.
 type Comparator[F any] interface {
 	Compare(a, b F) int
+	Equal(a, b F) bool
 	IsPareto() bool
 }
 
+// Tolerant is an optional interface for comparators that support near-optimal solutions. See [WithTolerance].
+//
+// Within must be monotonic like Compare: if a fitness is not within the tolerance,
+// no worse fitness found deeper in the search is within the tolerance either.
+type Tolerant[F any] interface {
+	// Within checks whether fitness a is not worse than fitness b by more than the given relative tolerance.
+	Within(a, b F, tolerance float64) bool
+}
+
 // Evaluator interface or deriving fitness from a solution.
 type Evaluator[F any] interface {
 	Evaluate(s []ActionDef) F
 }
 
+// Cloner is an optional interface for evaluators with internal state, like buffers or incremental state.
+//
+// Calls of [Solver.Solve] never share an evaluator. Sequential calls use the solver's evaluator.
+// If an evaluator implements Cloner, concurrent calls use clones of it, and run in parallel.
+// Otherwise, concurrent calls wait for each other to release the evaluator.
+//
+// Clone must return an evaluator that shares no mutable state with the original.
+// Types that embed a Cloner must override Clone, as the promoted method clones only the embedded evaluator.
+type Cloner[F any] interface {
+	Clone() Evaluator[F]
+}
+
 // Solver for optimization.
-type Solver[F comparable] struct {
-	bestFitness  F
-	evaluator    Evaluator[F]
-	comparator   Comparator[F]
-	problem      *Problem
-	solutions    []solution[F]
-	tempSolution []ActionDef
-}
-
-// NewSolver creates a new solver for a given fitness function.
-func NewSolver[F comparable](evaluator Evaluator[F], comparator Comparator[F]) Solver[F] {
-	return Solver[F]{
-		evaluator:  evaluator,
+//
+// A solver holds only its configuration, which is not modified by solving.
+// Each call of [Solver.Solve] runs in its own search state, so a solver can be reused for any number of problems,
+// and can be used by multiple goroutines concurrently. Concurrent calls run in parallel if the evaluator
+// implements [Cloner]. Branching strategies and constraints are shared by concurrent calls,
+// and must not have internal state, like all strategies and constraints of this package.
+type Solver[F any] struct {
+	comparator Comparator[F]
+	branching  BranchingStrategy
+	tolerant   Tolerant[F]
+	settings   settings
+	shared     *shared[F]
+}
+
+// shared is the state shared by all calls of [Solver.Solve] of a solver.
+type shared[F any] struct {
+	mu         sync.Mutex
+	released   *sync.Cond
+	evaluators []Evaluator[F] // Evaluators not in use. Initially the solver's evaluator.
+	cloner     Cloner[F]      // The solver's evaluator, if it can be cloned.
+	stats      Stats          // Statistics of the last completed call.
+}
+
+// acquire takes an evaluator for a call of [Solver.Solve].
+// If all evaluators are in use, it clones the solver's evaluator, or waits for an evaluator to be released.
+func (s *shared[F]) acquire() Evaluator[F] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.evaluators) == 0 {
+		if s.cloner != nil {
+			return s.cloner.Clone()
+		}
+		s.released.Wait()
+	}
+	e := s.evaluators[len(s.evaluators)-1]
+	s.evaluators = s.evaluators[:len(s.evaluators)-1]
+	return e
+}
+
+// release returns an evaluator after a call of [Solver.Solve], and stores the statistics of the call.
+func (s *shared[F]) release(e Evaluator[F], stats Stats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evaluators = append(s.evaluators, e)
+	s.stats = stats
+	s.released.Signal()
+}
+
+// search is the state of a single call of [Solver.Solve].
+type search[F any] struct {
+	bestFitness F
+	seeded      bool // Whether bestFitness is bounded by a greedy solution that is not in the archive.
+	evaluator   Evaluator[F]
+	incremental IncrementalEvaluator[F]
+	comparator  Comparator[F]
+	problem     *Problem
+	solutions   []solution[F]
+	coverage    *coverage
+	branching   BranchingStrategy
+	state       SearchState
+	times       []int // Stack of ordered times of the branching requirements.
+	constraints []Constraint
+	candidate   ActionDef // Action to check against constraints.
+	tolerant    Tolerant[F]
+	root        *Problem // Problem passed to Solve, as opposed to sub-problems.
+	// Channel for reporting improved solutions, for anytime solving.
+	improvements chan<- Solution[F]
+	settings     *settings
+	table        *transpositionTable
+	stats        Stats
+}
+
+// NewSolver creates a new solver for a given fitness function, with optional settings.
+func NewSolver[F any](evaluator Evaluator[F], comparator Comparator[F], options ...Option) Solver[F] {
+	s := Solver[F]{
 		comparator: comparator,
+		shared:     &shared[F]{evaluators: []Evaluator[F]{evaluator}},
 	}
+	s.shared.released = sync.NewCond(&s.shared.mu)
+	if c, ok := evaluator.(Cloner[F]); ok {
+		s.shared.cloner = c
+	}
+	for _, opt := range options {
+		opt(&s.settings)
+	}
+	if s.settings.tolerance > 0 {
+		tolerant, ok := comparator.(Tolerant[F])
+		if !ok && !comparator.IsPareto() {
+			panic(fmt.Sprintf("comparator %T does not support tolerance; it must implement Tolerant", comparator))
+		}
+		s.tolerant = tolerant
+	}
+	s.branching = s.settings.branching
+	if s.branching == nil {
+		s.branching = DefaultBranching{}
+	}
+	return s
+}
+
+// Stats returns statistics of the last completed call of [Solver.Solve] or [Solver.SolveAnytime].
+// With concurrent calls, this is the call that completed last.
+func (s *Solver[F]) Stats() Stats {
+	s.shared.mu.Lock()
+	defer s.shared.mu.Unlock()
+	return s.shared.stats
 }
 
 // Solve the given problem.
+//
+// Solutions with the same schedule, i.e. the same canonical actions, are reported only once.
+// See [Solution.Canonical].
+//
+// Solve may be called concurrently from multiple goroutines. See [Solver].
 func (s *Solver[F]) Solve(problem *Problem) ([]Solution[F], bool) {
-	s.problem = problem
-	s.solutions = []solution[F]{}
-	s.tempSolution = []ActionDef{}
+	return s.solve(problem, nil)
+}
+
+// SolveAnytime solves the given problem like [Solver.Solve], and reports improved solutions while solving.
+//
+// A greedy construction first finds an initial solution fast. Its fitness seeds the incumbent
+// for pruning the exact search, which then finds the same solutions as [Solver.Solve].
+// Each improved solution is sent to the given channel as soon as it is found, starting with the greedy one.
+// With [WithTopK] and without [WithTolerance], a single solution does not bound the search,
+// and the greedy construction is skipped.
+// For pareto comparators, each solution added to the pareto front is sent, even if it is dominated later.
+// The channel is closed when the search is complete.
+//
+// Solutions are sent from the calling goroutine, so the channel must be received from in another goroutine.
+// With decomposition, only solutions of the complete problem are sent, after all sub-problems are solved.
+func (s *Solver[F]) SolveAnytime(problem *Problem, improvements chan<- Solution[F]) ([]Solution[F], bool) {
+	defer close(improvements)
+	return s.solve(problem, improvements)
+}
+
+// solve solves the given problem in a new search state.
+func (s *Solver[F]) solve(problem *Problem, improvements chan<- Solution[F]) ([]Solution[F], bool) {
+	search := search[F]{
+		evaluator:    s.shared.acquire(),
+		comparator:   s.comparator,
+		branching:    s.branching,
+		tolerant:     s.tolerant,
+		settings:     &s.settings,
+		improvements: improvements,
+	}
+	defer func() { s.shared.release(search.evaluator, search.stats) }()
+	return search.run(problem)
+}
 
-	s.solve(&actions{})
+// run solves the given problem.
+func (s *search[F]) run(problem *Problem) ([]Solution[F], bool) {
+	s.root = problem
+	if s.settings.tableBytes > 0 {
+		s.table = newTranspositionTable(s.settings.tableBytes)
+		if s.table != nil {
+			s.stats.TableSize = len(s.table.entries)
+		}
+	}
+
+	// Near-optimal solutions of sub-problems don't combine to all near-optimal solutions of the problem.
+	if s.settings.decompose && s.settings.tolerance == 0 {
+		components := problem.decompose(s.settings.constraints)
+		for i := range components {
+			s.stats.Components = append(s.stats.Components, len(components[i].requirements))
+		}
+		if len(components) > 1 {
+			s.solveComponents(problem, components)
+		} else {
+			s.runProblem(problem)
+		}
+	} else {
+		s.runProblem(problem)
+	}
 
 	if len(s.solutions) > 0 {
 		return s.toSolutions(), true
@@ -194,169 +680,329 @@ func (s *Solver[F]) Solve(problem *Problem) ([]Solution[F], bool) {
 	return []Solution[F]{}, false
 }
 
+// runProblem solves a problem or sub-problem, and stores the results in the search state.
+func (s *search[F]) runProblem(problem *Problem) {
+	var zero F
+	s.bestFitness = zero
+	s.problem = problem
+	s.solutions = []solution[F]{}
+	if s.table != nil {
+		// Sub-problems of a decomposition must not share visited states.
+		clear(s.table.entries)
+	}
+
+	if pa, ok := s.evaluator.(ProblemAware); ok {
+		pa.Init(problem)
+	}
+	s.incremental = nil
+	if inc, ok := s.evaluator.(IncrementalEvaluator[F]); ok {
+		inc.Reset(problem.fixed)
+		s.incremental = inc
+	}
+
+	s.constraints = append(slices.Clone(problem.constraints), s.settings.constraints...)
+	s.coverage = newCoverage(problem)
+	sol := actions{Actions: slices.Clone(problem.fixed)}
+	s.state = SearchState{coverage: s.coverage, actions: &sol}
+
+	s.seeded = false
+	if s.improvements != nil && s.canSeed() {
+		found := s.greedy(&sol)
+		if !s.comparator.IsPareto() {
+			// Only the fitness is kept, as the exact search finds the greedy solution again.
+			s.solutions = s.solutions[:0]
+			s.seeded = found && s.archived()
+		}
+	}
+	for i := range sol.Actions {
+		s.coverage.Push(&sol.Actions[i], true)
+	}
+
+	s.solve(&sol)
+}
+
 // toSolutions converts the solution results to the solution output type,
 // translating integer IDs back to strings.
-func (s *Solver[F]) toSolutions() []Solution[F] {
+func (s *search[F]) toSolutions() []Solution[F] {
 	solutions := []Solution[F]{}
+	for i := range s.solutions {
+		solutions = append(solutions, s.toSolution(&s.solutions[i]))
+	}
+	return solutions
+}
 
-	for _, sol := range s.solutions {
-		actions := make([]Action, len(sol.Actions))
+// toSolution converts a single solution to the solution output type.
+func (s *search[F]) toSolution(sol *solution[F]) Solution[F] {
+	actions := make([]Action, len(sol.Actions))
 
-		for i := range sol.Actions {
-			a := &sol.Actions[i]
-			var reuse string
-			if a.Reuse >= 0 {
-				reuse = s.problem.subjectNames[a.Reuse]
-			}
-			actions[i] = Action{
-				Subject:       s.problem.subjectNames[a.Subject],
-				Matrix:        s.problem.matrixNames[a.Matrix],
-				Samples:       a.Samples,
-				TargetSamples: a.TargetSamples,
-				Time:          a.Time,
-				Reuse:         reuse,
-			}
+	for i := range sol.Actions {
+		a := &sol.Actions[i]
+		var reuse string
+		if a.Reuse >= 0 {
+			reuse = s.problem.subjectNames[a.Reuse]
+		}
+		var label string
+		if s.problem.timeline != nil {
+			label = s.problem.timeline.Label(a.Time)
 		}
+		actions[i] = Action{
+			Subject:       s.problem.subjectNames[a.Subject],
+			Matrix:        s.problem.matrixNames[a.Matrix],
+			Site:          s.problem.siteNames[a.Site],
+			Samples:       a.Samples,
+			TargetSamples: a.TargetSamples,
+			Time:          a.Time,
+			Label:         label,
+			Reuse:         reuse,
+		}
+	}
 
-		solutions = append(solutions, Solution[F]{
-			Actions: actions,
-			Fitness: sol.Fitness,
-		})
+	var submissions []Submission
+	if len(s.problem.labs) > 0 {
+		actions, submissions = s.problem.assignLabs(actions)
 	}
 
-	return solutions
+	return Solution[F]{
+		Actions:     actions,
+		Fitness:     sol.Fitness,
+		Tours:       s.problem.tours(sol.Actions),
+		Submissions: submissions,
+	}
 }
 
 // Recursive solver function.
-func (s *Solver[F]) solve(sol *actions) {
-	fitness := s.evaluator.Evaluate(sol.Actions)
+func (s *search[F]) solve(sol *actions) {
+	s.stats.Nodes++
+
+	var fitness F
+	if s.incremental != nil {
+		fitness = s.incremental.Fitness()
+	} else {
+		fitness = s.evaluator.Evaluate(sol.Actions)
+	}
 
 	if s.comparator.IsPareto() {
 		if !s.isParetoOptimal(fitness, false) {
 			return
 		}
+	} else if s.archived() {
+		if !s.mayEnterArchive(fitness) {
+			return
+		}
 	} else {
 		if s.comparator.Compare(fitness, s.bestFitness) > 0 {
 			return
 		}
 	}
 
-	var unsatisfied *requirement = nil
-	var requiredSamples = 0
+	if s.table != nil {
+		s.stats.Probes++
+		if s.table.Visit(s.coverage.hash) {
+			s.stats.Hits++
+			return
+		}
+	}
 
-	capacity := slices.Clone(s.problem.capacity)
+	r := s.branching.Select(&s.state)
+	if r >= 0 {
+		unsatisfied := &s.problem.requirements[r]
+		requiredSamples := s.coverage.remaining[r]
 
-	for r := range s.problem.requirements {
-		req := &s.problem.requirements[r]
-		samples := req.Samples
-		for a := range sol.Actions {
-			act := &sol.Actions[a]
+		first := len(s.times)
+		s.times = append(s.times, unsatisfied.Times...)
+		s.branching.Order(&s.state, r, s.times[first:])
 
-			if !slices.Contains(req.Times, act.Time) {
+		siteCapacity := s.coverage.capacity[unsatisfied.Site]
+		for i := first; i < first+len(unsatisfied.Times); i++ {
+			t := s.times[i]
+			if siteCapacity[t] <= 0 {
 				continue
 			}
-			if !s.problem.reusable[req.Matrix][act.Matrix] {
+
+			s.candidate = ActionDef{
+				Subject:       unsatisfied.Subject,
+				Matrix:        unsatisfied.Matrix,
+				Site:          unsatisfied.Site,
+				Samples:       min(requiredSamples, siteCapacity[t]),
+				TargetSamples: unsatisfied.Samples,
+				Time:          t,
+				Reuse:         NoSubject,
+			}
+			if !s.allow(&s.candidate) {
 				continue
 			}
 
-			equivalentSamples := min(act.Samples, samples)
-			ownSample := req.Subject == act.Subject
-			if ownSample {
-				equivalentSamples = min(equivalentSamples, capacity[act.Time])
+			sol.Actions = append(sol.Actions, s.candidate)
+			action := &sol.Actions[len(sol.Actions)-1]
+			s.coverage.Push(action, false)
+			if s.incremental != nil {
+				s.incremental.Push(*action)
 			}
 
-			samples -= equivalentSamples
-
-			if equivalentSamples > 0 {
-				reuse := subject(-1)
-				if !ownSample {
-					reuse = act.Subject
-				}
-				s.tempSolution = append(s.tempSolution, ActionDef{
-					Subject:       req.Subject,
-					Matrix:        req.Matrix,
-					Samples:       equivalentSamples,
-					Time:          act.Time,
-					TargetSamples: req.Samples,
-					Reuse:         reuse,
-				})
-				if ownSample {
-					capacity[act.Time] -= equivalentSamples
-				}
+			s.solve(sol)
+
+			if s.incremental != nil {
+				s.incremental.Pop()
 			}
-			if samples == 0 {
-				break
+			s.coverage.Pop(&sol.Actions[len(sol.Actions)-1])
+			sol.Actions = sol.Actions[:len(sol.Actions)-1]
+		}
+		s.times = s.times[:first]
+	} else if s.feasible() {
+		s.accept(fitness, func() solution[F] {
+			return solution[F]{
+				Actions:   s.coverage.Actions(nil),
+				Decisions: slices.Clone(sol.Actions),
 			}
+		})
+	}
+}
+
+// allow checks whether an action is allowed by all constraints.
+func (s *search[F]) allow(action *ActionDef) bool {
+	for _, c := range s.constraints {
+		if !c.Allow(&s.state, action) {
+			return false
 		}
+	}
+	return true
+}
 
-		if samples > 0 {
-			if unsatisfied == nil {
-				unsatisfied = req
-				requiredSamples = samples
-			} else {
-				// for the same matrix, prefer the larger sample.
-				if req.Matrix == unsatisfied.Matrix {
-					if samples > requiredSamples {
-						unsatisfied = req
-						requiredSamples = samples
-					}
-					// if not the same matrix, prefer the one that can be re-used by the other.
-				} else if s.problem.reusable[unsatisfied.Matrix][req.Matrix] {
-					unsatisfied = req
-					requiredSamples = samples
-				}
-			}
+// feasible checks whether the complete schedule of the current state is accepted by all constraints.
+func (s *search[F]) feasible() bool {
+	for _, c := range s.constraints {
+		if !c.Accept(&s.state) {
+			return false
 		}
 	}
+	return true
+}
 
-	if unsatisfied != nil {
-		for _, t := range unsatisfied.Times {
-			if capacity[t] <= 0 {
-				continue
-			}
+// accept adds a solution with the given fitness to the solver's solutions, if it is optimal.
+// The solution is only created if it is accepted.
+func (s *search[F]) accept(fitness F, create func() solution[F]) {
+	if s.comparator.IsPareto() {
+		if s.isParetoOptimal(fitness, true) {
+			sol := create()
+			sol.Fitness = fitness
+			s.solutions = append(s.solutions, sol)
+			s.improved(&sol)
+		}
+		return
+	}
+	if s.archived() {
+		s.acceptArchive(fitness, create)
+		return
+	}
+	comp := s.comparator.Compare(fitness, s.bestFitness)
+	if comp < 0 {
+		s.solutions = s.solutions[:0]
+	}
+	if comp <= 0 {
+		s.bestFitness = fitness
+		sol := create()
+		sol.Fitness = fitness
+		if comp == 0 && s.isDuplicate(&sol) {
+			return
+		}
+		s.solutions = append(s.solutions, sol)
+		if comp < 0 {
+			s.improved(&sol)
+		}
+	}
+}
 
-			sol.Actions = append(sol.Actions, ActionDef{
-				Subject:       unsatisfied.Subject,
-				Matrix:        unsatisfied.Matrix,
-				Samples:       min(requiredSamples, capacity[t]),
-				TargetSamples: unsatisfied.Samples,
-				Time:          t,
-				Reuse:         -1,
-			})
-			s.tempSolution = s.tempSolution[:0]
+// canSeed checks whether the fitness of a greedy solution can be used for pruning.
+// With an archive of the top k solutions only, it does not bound the k-th best solution.
+func (s *search[F]) canSeed() bool {
+	return s.comparator.IsPareto() || !s.archived() || s.settings.tolerance > 0
+}
 
-			s.solve(sol)
+// archived checks whether near-best solutions are kept in an archive, with top-k or tolerance.
+func (s *search[F]) archived() bool {
+	return s.settings.topK > 0 || s.settings.tolerance > 0
+}
 
-			sol.Actions = sol.Actions[:len(sol.Actions)-1]
+// mayEnterArchive checks whether a fitness may enter the archive of near-best solutions.
+// As fitness only gets worse deeper in the search, it is also used for pruning.
+func (s *search[F]) mayEnterArchive(fitness F) bool {
+	if len(s.solutions) == 0 && !s.seeded {
+		return true
+	}
+	// The best fitness is the best in the archive, or the greedy fitness if that is better.
+	if s.settings.tolerance > 0 && !s.tolerant.Within(fitness, s.bestFitness, s.settings.tolerance) {
+		return false
+	}
+	if s.settings.topK > 0 && len(s.solutions) >= s.settings.topK &&
+		s.comparator.Compare(fitness, s.solutions[len(s.solutions)-1].Fitness) >= 0 {
+		return false
+	}
+	return true
+}
+
+// acceptArchive adds a solution to the archive of near-best solutions, if it qualifies.
+// The archive is sorted by fitness, and solutions that no longer qualify are removed.
+func (s *search[F]) acceptArchive(fitness F, create func() solution[F]) {
+	if !s.mayEnterArchive(fitness) {
+		return
+	}
+	idx := len(s.solutions)
+	for i := range s.solutions {
+		if s.comparator.Compare(fitness, s.solutions[i].Fitness) < 0 {
+			idx = i
+			break
 		}
-	} else {
-		if s.comparator.IsPareto() {
-			if s.isParetoOptimal(fitness, true) {
-				s.solutions = append(s.solutions, solution[F]{
-					Actions: slices.Clone(s.tempSolution),
-					Fitness: fitness,
-				})
-				s.tempSolution = s.tempSolution[:0]
-			}
-		} else {
-			comp := s.comparator.Compare(fitness, s.bestFitness)
-			if comp < 0 {
-				s.solutions = s.solutions[:0]
-			}
-			if comp <= 0 {
-				s.bestFitness = fitness
-				s.solutions = append(s.solutions, solution[F]{
-					Actions: slices.Clone(s.tempSolution),
-					Fitness: fitness,
-				})
-				s.tempSolution = s.tempSolution[:0]
-			}
+	}
+	sol := create()
+	sol.Fitness = fitness
+	if s.isDuplicate(&sol) {
+		return
+	}
+	s.solutions = slices.Insert(s.solutions, idx, sol)
+
+	if s.settings.topK > 0 && len(s.solutions) > s.settings.topK {
+		s.solutions = s.solutions[:s.settings.topK]
+	}
+	if idx == 0 {
+		if s.settings.tolerance > 0 {
+			s.solutions = slices.DeleteFunc(s.solutions, func(other solution[F]) bool {
+				return !s.tolerant.Within(other.Fitness, fitness, s.settings.tolerance)
+			})
 		}
+		if !s.seeded || s.comparator.Compare(fitness, s.bestFitness) < 0 {
+			s.bestFitness = fitness
+			s.improved(&sol)
+		}
+	}
+}
+
+// isDuplicate checks whether a solution has the same canonical actions as any of the solver's solutions.
+// See [Solution.Canonical].
+func (s *search[F]) isDuplicate(sol *solution[F]) bool {
+	sol.canonical = canonicalActions(s.problem, sol.Actions)
+	sol.hash = hashActions(sol.canonical)
+	for i := range s.solutions {
+		other := &s.solutions[i]
+		if other.canonical == nil {
+			other.canonical = canonicalActions(s.problem, other.Actions)
+			other.hash = hashActions(other.canonical)
+		}
+		if other.hash == sol.hash && slices.Equal(other.canonical, sol.canonical) {
+			return true
+		}
+	}
+	return false
+}
+
+// improved reports an improved solution of the complete problem, for anytime solving.
+func (s *search[F]) improved(sol *solution[F]) {
+	if s.improvements != nil && s.problem == s.root {
+		s.improvements <- s.toSolution(sol)
 	}
 }
 
 // removeSolution swap-removes the solution at the given index.
-func (s *Solver[F]) removeSolution(idx int) {
+func (s *search[F]) removeSolution(idx int) {
 	ln := len(s.solutions) - 1
 	s.solutions[idx], s.solutions[ln] = s.solutions[ln], s.solutions[idx]
 	s.solutions = s.solutions[:ln]
@@ -364,7 +1010,7 @@ func (s *Solver[F]) removeSolution(idx int) {
 
 // isParetoOptimal checks if the given fitness is pareto-optimal and should be retained as a solution.
 // If argument remove is true, all non-optimal solutions are removed from the Solver.
-func (s *Solver[F]) isParetoOptimal(f F, remove bool) bool {
+func (s *search[F]) isParetoOptimal(f F, remove bool) bool {
 	betterThanAny := len(s.solutions) == 0
 	hasDuplicate := false
 	for i := len(s.solutions) - 1; i >= 0; i-- {
@@ -374,7 +1020,7 @@ func (s *Solver[F]) isParetoOptimal(f F, remove bool) bool {
 			if remove {
 				s.removeSolution(i)
 			}
-		} else if f == s.solutions[i].Fitness {
+		} else if s.comparator.Equal(f, s.solutions[i].Fitness) {
 			hasDuplicate = true
 		}
 
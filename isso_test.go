@@ -2,10 +2,15 @@ package isso_test
 
 import (
 	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sync"
 	"testing"
 
 	"github.com/mlange-42/isso"
 	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestDefaultProblem(t *testing.T) {
@@ -143,3 +148,430 @@ func TestParetoProblem(t *testing.T) {
 	}
 	fmt.Println("No solution found")
 }
+
+func TestSitesProblem(t *testing.T) {
+	matrices := []isso.Matrix{
+		{Name: "fruits", CanReuse: []string{}},
+	}
+
+	sites := []isso.Site{
+		{Name: "Orchard A", Capacity: []int{100, 100, 100}},
+		{Name: "Orchard B", Capacity: []int{100, 100, 100}},
+	}
+
+	requirements := []isso.Requirement{
+		{Subject: "Pest 1", Site: "Orchard A", Matrix: "fruits", Samples: 100, Times: []int{0, 1}},
+		{Subject: "Pest 2", Site: "Orchard A", Matrix: "fruits", Samples: 50, Times: []int{1, 2}},
+		{Subject: "Pest 3", Site: "Orchard B", Matrix: "fruits", Samples: 100, Times: []int{1}},
+	}
+
+	p := isso.NewProblem(
+		isso.ProblemDef{
+			Matrices:     matrices,
+			Sites:        sites,
+			Requirements: requirements,
+		},
+	)
+
+	s := isso.NewSolver(
+		&fitness.TripsAndSamplesEvaluator{},
+		&fitness.TripsThenSamples{},
+	)
+
+	solution, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(solution))
+	assert.Equal(t, fitness.TripsAndSamplesFitness{Trips: 2, Samples: 200}, solution[0].Fitness)
+
+	for _, a := range solution[0].Actions {
+		if a.Subject == "Pest 3" {
+			assert.Equal(t, "Orchard B", a.Site)
+			assert.Equal(t, "", a.Reuse)
+		} else {
+			assert.Equal(t, "Orchard A", a.Site)
+		}
+	}
+
+	assert.Contains(t, solution[0].ToTable(), "Orchard B")
+	assert.Contains(t, solution[0].ToList(), "Site = Orchard B")
+	assert.Contains(t, solution[0].ToCSV(0, ","), "Solution,Site,Subject")
+}
+
+func TestSitesUnevenCapacity(t *testing.T) {
+	p := isso.NewProblem(
+		isso.ProblemDef{
+			Matrices: []isso.Matrix{{Name: "fruits"}},
+			Sites: []isso.Site{
+				{Name: "Orchard A", Capacity: []int{100, 100, 100}},
+				{Name: "Orchard B", Capacity: []int{0, 100}},
+			},
+			Requirements: []isso.Requirement{
+				{Subject: "Pest 1", Site: "Orchard A", Matrix: "fruits", Samples: 100, Times: []int{2}},
+				{Subject: "Pest 2", Site: "Orchard B", Matrix: "fruits", Samples: 100, Times: []int{1, 2}},
+			},
+		},
+	)
+	assert.Equal(t, 3, p.NumTimes())
+	assert.Equal(t, [][]int{{100, 100, 100}, {0, 100, 0}}, p.Capacity())
+
+	s := isso.NewSolver(
+		&fitness.TripsAndSamplesEvaluator{},
+		&fitness.TripsThenSamples{},
+	)
+	solution, ok := s.Solve(&p)
+	assert.True(t, ok)
+	for _, a := range solution[0].Actions {
+		if a.Subject == "Pest 2" {
+			assert.Equal(t, 1, a.Time)
+		}
+	}
+}
+
+func TestTravelProblem(t *testing.T) {
+	problem := isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}},
+		Sites: []isso.Site{
+			{Name: "A", Capacity: []int{100, 100}},
+			{Name: "B", Capacity: []int{100, 100}},
+		},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Site: "A", Matrix: "fruits", Samples: 100, Times: []int{0}},
+			{Subject: "Pest 2", Site: "B", Matrix: "fruits", Samples: 100, Times: []int{0, 1}},
+		},
+		Travel: &isso.Travel{
+			Depot: "Depot",
+			Durations: [][]float64{
+				{0, 1, 2},
+				{1, 0, 2},
+				{2, 3, 0},
+			},
+		},
+	}
+	p := isso.NewProblem(problem)
+
+	s := isso.NewSolver(
+		&fitness.TripsAndSamplesEvaluator{CombineSites: true},
+		&fitness.TripsThenSamples{},
+	)
+	solution, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(solution))
+	assert.Equal(t, []isso.Tour{
+		{Time: 0, Stops: []string{"Depot", "A", "B", "Depot"}, Duration: 5},
+	}, solution[0].Tours)
+	assert.Equal(t, "Time =  0:     5.00  Depot -> A -> B -> Depot", solution[0].ToTours())
+
+	problem.Travel.MaxDuration = 4.5
+	p = isso.NewProblem(problem)
+	s = isso.NewSolver(
+		&fitness.TripsAndSamplesEvaluator{CombineSites: true},
+		&fitness.TripsThenSamples{},
+	)
+	solution, ok = s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(solution))
+	assert.Equal(t, fitness.TripsAndSamplesFitness{Trips: 2, Samples: 200}, solution[0].Fitness)
+	assert.Equal(t, 2, len(solution[0].Tours))
+}
+
+func TestTravelLargeTours(t *testing.T) {
+	// Random durations, where the heuristic tour of the 12 sites is shorter than that of any 11 of them.
+	rng := rand.New(rand.NewSource(1465))
+	durations := make([][]float64, 13)
+	for i := range durations {
+		durations[i] = make([]float64, 13)
+		for j := range durations[i] {
+			if i != j {
+				durations[i][j] = float64(1 + rng.Intn(9))
+			}
+		}
+	}
+
+	problem := isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}},
+		Travel:   &isso.Travel{Depot: "Depot", Durations: durations, MaxDuration: 26},
+	}
+	for i := 0; i < 12; i++ {
+		site := fmt.Sprintf("S%d", i)
+		problem.Sites = append(problem.Sites, isso.Site{Name: site, Capacity: []int{100}})
+		problem.Requirements = append(problem.Requirements,
+			isso.Requirement{Subject: fmt.Sprintf("Pest %d", i), Site: site, Matrix: "fruits", Samples: 10, Times: []int{0}})
+	}
+	p := isso.NewProblem(problem)
+
+	s := isso.NewSolver(&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{})
+	solution, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(solution[0].Tours))
+	assert.Equal(t, 26.0, solution[0].Tours[0].Duration)
+	assert.Empty(t, p.Verify(solution[0].Actions))
+
+	problem.Travel.MaxDuration = 25
+	p = isso.NewProblem(problem)
+	_, ok = s.Solve(&p)
+	assert.False(t, ok)
+}
+
+type awareEvaluator struct {
+	fitness.TripsAndSamplesEvaluator
+	problem *isso.Problem
+}
+
+func (e *awareEvaluator) Init(p *isso.Problem) {
+	e.problem = p
+}
+
+func TestProblemAware(t *testing.T) {
+	p := isso.NewProblem(
+		isso.ProblemDef{
+			Matrices: []isso.Matrix{
+				{Name: "fruits & shoots", CanReuse: []string{}},
+				{Name: "fruits", CanReuse: []string{"fruits & shoots"}},
+			},
+			Sites: []isso.Site{
+				{Name: "Orchard A", Capacity: []int{100, 100, 100}},
+				{Name: "Orchard B", Capacity: []int{100, 100}},
+			},
+			Requirements: []isso.Requirement{
+				{Subject: "Pest 1", Site: "Orchard A", Matrix: "fruits", Samples: 100, Times: []int{1, 0}},
+				{Subject: "Pest 2", Site: "Orchard B", Matrix: "fruits & shoots", Samples: 50, Times: []int{1}},
+			},
+			FixedActions: []isso.Action{
+				{Subject: "Pest 2", Time: 1, Samples: 50},
+			},
+		},
+	)
+
+	eval := awareEvaluator{}
+	s := isso.NewSolver[fitness.TripsAndSamplesFitness](&eval, &fitness.TripsThenSamples{})
+	_, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, &p, eval.problem)
+
+	assert.Equal(t, "Pest 2", p.SubjectName(1))
+	assert.Equal(t, "fruits", p.MatrixName(1))
+	assert.Equal(t, "Orchard B", p.SiteName(1))
+	assert.Equal(t, 3, p.NumTimes())
+	assert.True(t, p.CanReuse(1, 0))
+	assert.False(t, p.CanReuse(0, 1))
+	assert.Nil(t, p.Timeline())
+
+	capacity := p.Capacity()
+	assert.Equal(t, [][]int{{100, 100, 100}, {100, 50, 0}}, capacity)
+	capacity[0][0] = 0
+	assert.Equal(t, [][]int{{100, 100, 100}, {100, 50, 0}}, p.Capacity())
+
+	assert.Equal(t, []isso.Requirement{
+		{Subject: "Pest 1", Site: "Orchard A", Matrix: "fruits", Samples: 100, Times: []int{0, 1}},
+		{Subject: "Pest 2", Site: "Orchard B", Matrix: "fruits & shoots", Samples: 50, Times: []int{1}},
+	}, p.Requirements())
+}
+
+func TestProblemIDs(t *testing.T) {
+	p := isso.NewProblem(
+		isso.ProblemDef{
+			Matrices: []isso.Matrix{
+				{Name: "fruits & shoots", CanReuse: []string{}},
+				{Name: "fruits", CanReuse: []string{"fruits & shoots"}},
+			},
+			Sites: []isso.Site{
+				{Name: "Orchard A", Capacity: []int{100, 100}},
+				{Name: "Orchard B", Capacity: []int{100, 100}},
+			},
+			Requirements: []isso.Requirement{
+				{Subject: "Pest 1", Site: "Orchard B", Matrix: "fruits & shoots", Samples: 100, Times: []int{0, 1}},
+				{Subject: "Pest 2", Site: "Orchard B", Matrix: "fruits", Samples: 50, Times: []int{1}},
+			},
+		},
+	)
+
+	sub, ok := p.SubjectID("Pest 2")
+	assert.True(t, ok)
+	assert.Equal(t, isso.SubjectID(1), sub)
+	assert.Equal(t, "Pest 2", p.SubjectName(sub))
+	_, ok = p.SubjectID("Pest 3")
+	assert.False(t, ok)
+
+	mat, ok := p.MatrixID("fruits")
+	assert.True(t, ok)
+	assert.Equal(t, isso.MatrixID(1), mat)
+	_, ok = p.MatrixID("shoots")
+	assert.False(t, ok)
+
+	site, ok := p.SiteID("Orchard B")
+	assert.True(t, ok)
+	assert.Equal(t, isso.SiteID(1), site)
+	_, ok = p.SiteID("Orchard C")
+	assert.False(t, ok)
+
+	src, _ := p.SubjectID("Pest 1")
+	srcMat, _ := p.MatrixID("fruits & shoots")
+	actions := []isso.ActionDef{
+		isso.NewActionDef(src, srcMat, site, 1, 100, isso.NoSubject),
+		isso.NewActionDef(sub, mat, site, 1, 50, src),
+	}
+	assert.Equal(t, isso.ActionDef{Subject: 1, Matrix: 1, Reuse: 0, Site: 1, Time: 1, Samples: 50, TargetSamples: 50}, actions[1])
+
+	eval := fitness.TripsAndSamplesEvaluator{}
+	assert.Equal(t, fitness.TripsAndSamplesFitness{Trips: 1, Samples: 100}, eval.Evaluate(actions))
+}
+
+// fitnessList returns the fitness values of solutions, in order.
+func fitnessList(solutions []isso.Solution[fitness.TripsAndSamplesFitness]) []fitness.TripsAndSamplesFitness {
+	list := make([]fitness.TripsAndSamplesFitness, len(solutions))
+	for i, sol := range solutions {
+		list[i] = sol.Fitness
+	}
+	return list
+}
+
+func TestTopK(t *testing.T) {
+	comp := &fitness.TripsThenSamples{}
+	for seed := int64(0); seed < 10; seed++ {
+		msg := fmt.Sprintf("seed %d", seed)
+		p := isso.NewProblem(generateProblem(seed, 5, 8, 2))
+
+		s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp, isso.WithTopK(math.MaxInt))
+		all, ok := s.Solve(&p)
+		if !ok {
+			continue
+		}
+		assert.True(t, slices.IsSortedFunc(all, func(a, b isso.Solution[fitness.TripsAndSamplesFitness]) int {
+			return comp.Compare(a.Fitness, b.Fitness)
+		}), msg)
+
+		s = isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp, isso.WithTopK(5))
+		top, ok := s.Solve(&p)
+		assert.True(t, ok, msg)
+		assert.Equal(t, min(5, len(all)), len(top), msg)
+		assert.Equal(t, fitnessList(all[:len(top)]), fitnessList(top), msg)
+
+		s = isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp, isso.WithTolerance(0.1))
+		near, ok := s.Solve(&p)
+		assert.True(t, ok, msg)
+		expected := []fitness.TripsAndSamplesFitness{}
+		for _, sol := range all {
+			if comp.Within(sol.Fitness, all[0].Fitness, 0.1) {
+				expected = append(expected, sol.Fitness)
+			}
+		}
+		assert.Equal(t, expected, fitnessList(near), msg)
+
+		s = isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp,
+			isso.WithTolerance(0.1), isso.WithTopK(3))
+		both, ok := s.Solve(&p)
+		assert.True(t, ok, msg)
+		assert.Equal(t, expected[:min(3, len(expected))], fitnessList(both), msg)
+	}
+}
+
+func TestTopKDecomposed(t *testing.T) {
+	p := isso.NewProblem(generateProblem(1, 6, 16, 2))
+	comp := &fitness.TripsThenSamples{}
+
+	s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp, isso.WithTopK(4))
+	expected, ok := s.Solve(&p)
+	assert.True(t, ok)
+
+	s = isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp,
+		isso.WithTopK(4), isso.WithDecomposition())
+	solutions, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Greater(t, len(s.Stats().Components), 1)
+	assert.Equal(t, fitnessList(expected), fitnessList(solutions))
+}
+
+func TestToleranceUnsupported(t *testing.T) {
+	assert.NotPanics(t, func() {
+		isso.NewSolver[fitness.TripsAndSamplesFitness](
+			&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsSamplesPareto{}, isso.WithTolerance(0.1),
+		)
+	})
+	assert.Panics(t, func() {
+		isso.NewSolver[fitness.TripsAndSamplesFitness](
+			&fitness.TripsAndSamplesEvaluator{}, &unsupportedComparator{}, isso.WithTolerance(0.1),
+		)
+	})
+}
+
+// unsupportedComparator is a comparator that does not implement isso.Tolerant.
+type unsupportedComparator struct {
+	fitness.TripsSamplesPareto
+}
+
+func (c *unsupportedComparator) IsPareto() bool {
+	return false
+}
+
+func TestSolverReuse(t *testing.T) {
+	problems := make([]isso.Problem, 6)
+	expected := make([][]isso.Solution[fitness.TripsAndSamplesFitness], len(problems))
+	for i := range problems {
+		problems[i] = isso.NewProblem(generateProblem(int64(i), 5, 8, 2))
+		s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{})
+		expected[i], _ = s.Solve(&problems[i])
+	}
+
+	s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{})
+	for _, i := range []int{0, 1, 2, 3, 4, 5, 5, 4, 3, 2, 1, 0} {
+		solutions, _ := s.Solve(&problems[i])
+		assert.Equal(t, expected[i], solutions, fmt.Sprintf("problem %d", i))
+	}
+}
+
+// plainEvaluator is an evaluator with internal state that does not implement isso.Cloner.
+type plainEvaluator struct {
+	evaluator fitness.TripsAndSamplesEvaluator
+}
+
+func (e *plainEvaluator) Evaluate(sol []isso.ActionDef) fitness.TripsAndSamplesFitness {
+	return e.evaluator.Evaluate(sol)
+}
+
+func TestSolverConcurrent(t *testing.T) {
+	problems := make([]isso.Problem, 8)
+	for i := range problems {
+		problems[i] = isso.NewProblem(generateProblem(int64(i), 5, 8, 2))
+	}
+
+	configs := []struct {
+		name       string
+		evaluator  func() isso.Evaluator[fitness.TripsAndSamplesFitness]
+		comparator isso.Comparator[fitness.TripsAndSamplesFitness]
+		options    []isso.Option
+	}{
+		{"default", func() isso.Evaluator[fitness.TripsAndSamplesFitness] { return &fitness.TripsAndSamplesEvaluator{} },
+			&fitness.TripsThenSamples{}, nil},
+		{"pareto", func() isso.Evaluator[fitness.TripsAndSamplesFitness] { return &fitness.TripsAndSamplesEvaluator{} },
+			&fitness.TripsSamplesPareto{}, []isso.Option{isso.WithTranspositionTable(1 << 16)}},
+		{"top-k", func() isso.Evaluator[fitness.TripsAndSamplesFitness] { return &fitness.TripsAndSamplesEvaluator{} },
+			&fitness.TripsThenSamples{}, []isso.Option{isso.WithTopK(3), isso.WithDecomposition()}},
+		{"not cloneable", func() isso.Evaluator[fitness.TripsAndSamplesFitness] { return &plainEvaluator{} },
+			&fitness.TripsThenSamples{}, nil},
+	}
+
+	for _, c := range configs {
+		expected := make([][]isso.Solution[fitness.TripsAndSamplesFitness], len(problems))
+		for i := range problems {
+			s := isso.NewSolver(c.evaluator(), c.comparator, c.options...)
+			expected[i], _ = s.Solve(&problems[i])
+		}
+
+		s := isso.NewSolver(c.evaluator(), c.comparator, c.options...)
+		results := make([][]isso.Solution[fitness.TripsAndSamplesFitness], 2*len(problems))
+		var wg sync.WaitGroup
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], _ = s.Solve(&problems[i%len(problems)])
+			}()
+		}
+		wg.Wait()
+
+		for i := range results {
+			assert.Equal(t, expected[i%len(problems)], results[i], fmt.Sprintf("%s, problem %d", c.name, i%len(problems)))
+		}
+		assert.Greater(t, s.Stats().Nodes, 0)
+	}
+}
@@ -0,0 +1,219 @@
+package isso
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Lab definition, for routing samples to laboratories.
+//
+// Labs are assigned after solving, as a best-effort submission manifest for the solutions found.
+// Lab capacity does not constrain the search.
+// Samples that exceed the capacity of all suitable labs are reported as unassigned, see [Submission].
+type Lab struct {
+	Name       string
+	Subjects   []string // Subjects the lab can analyse.
+	Capacity   []int    // Maximum number of assays per time step.
+	Turnaround int      // Number of time steps until results are available.
+	// Cost per assay, for choosing between labs.
+	// With [ProblemDef.Costs], it is also the assay cost of the lab's subjects in cost-based fitness,
+	// for subjects without an entry in [Costs.Assays]. The cheapest lab for a subject is used.
+	Cost float64
+}
+
+// Submission of samples to a lab, as an entry of a lab submission manifest.
+type Submission struct {
+	Time         int
+	Label        string // Label or date of the time step, if the problem has a timeline.
+	Lab          string // Name of the lab. Empty if no lab with free capacity can analyse the subject.
+	Subject      string
+	Matrix       string
+	Samples      int
+	Results      int    // Time step when results are available. -1 for unassigned samples.
+	ResultsLabel string // Label or date of the results time step, if the problem has a timeline.
+}
+
+// validateLabs checks lab definitions for errors.
+func validateLabs(labs []Lab, subjects map[string]SubjectID) error {
+	names := map[string]bool{}
+	for _, lab := range labs {
+		if names[lab.Name] {
+			return fmt.Errorf("duplicate lab '%v'", lab.Name)
+		}
+		names[lab.Name] = true
+		for _, sub := range lab.Subjects {
+			if _, ok := subjects[sub]; !ok {
+				return fmt.Errorf("unknown subject '%v' in lab '%v'", sub, lab.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// assignLabs assigns the assays of all actions to labs, and derives the lab submission manifest.
+//
+// Each action requires an assay per sample for its subject. Actions are assigned to the cheapest lab
+// that can analyse the subject and has free capacity at the action's time. Ties are resolved
+// by the shorter turnaround time. Actions are split between labs where necessary.
+// Subjects that can be analysed by fewer labs are assigned first.
+// Samples that can't be assigned remain in actions without lab.
+func (p *Problem) assignLabs(actions []Action) ([]Action, []Submission) {
+	labs := make([]int, len(p.labs))
+	for i := range labs {
+		labs[i] = i
+	}
+	slices.SortStableFunc(labs, func(a, b int) int {
+		if c := cmp.Compare(p.labs[a].Cost, p.labs[b].Cost); c != 0 {
+			return c
+		}
+		return cmp.Compare(p.labs[a].Turnaround, p.labs[b].Turnaround)
+	})
+
+	capacity := make([][]int, len(p.labs))
+	for i := range p.labs {
+		capacity[i] = slices.Clone(p.labs[i].Capacity)
+	}
+
+	numLabs := func(a *Action) int {
+		cnt := 0
+		for i := range p.labs {
+			if slices.Contains(p.labs[i].Subjects, a.Subject) {
+				cnt++
+			}
+		}
+		return cnt
+	}
+	order := make([]int, len(actions))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(numLabs(&actions[a]), numLabs(&actions[b]))
+	})
+
+	split := make([][]Action, len(actions))
+	for _, idx := range order {
+		a := actions[idx]
+		remaining := a.Samples
+		for _, l := range labs {
+			lab := &p.labs[l]
+			if remaining == 0 {
+				break
+			}
+			if a.Time >= len(capacity[l]) || capacity[l][a.Time] <= 0 || !slices.Contains(lab.Subjects, a.Subject) {
+				continue
+			}
+			samples := min(remaining, capacity[l][a.Time])
+			capacity[l][a.Time] -= samples
+			remaining -= samples
+
+			act := a
+			act.Samples = samples
+			act.Lab = lab.Name
+			split[idx] = append(split[idx], act)
+		}
+		if remaining > 0 {
+			act := a
+			act.Samples = remaining
+			act.Lab = ""
+			split[idx] = append(split[idx], act)
+		}
+	}
+
+	assigned := make([]Action, 0, len(actions))
+	for _, acts := range split {
+		assigned = append(assigned, acts...)
+	}
+
+	return assigned, p.manifest(assigned)
+}
+
+// manifest aggregates actions with assigned labs to a lab submission manifest.
+// Submissions are sorted by time and lab, with unassigned samples last.
+func (p *Problem) manifest(actions []Action) []Submission {
+	turnaround := map[string]int{}
+	for _, lab := range p.labs {
+		turnaround[lab.Name] = lab.Turnaround
+	}
+
+	type key struct {
+		Time    int
+		Lab     string
+		Subject string
+	}
+	index := map[key]int{}
+	manifest := []Submission{}
+	for _, a := range actions {
+		k := key{a.Time, a.Lab, a.Subject}
+		if i, ok := index[k]; ok {
+			manifest[i].Samples += a.Samples
+			continue
+		}
+		results := -1
+		if a.Lab != "" {
+			results = a.Time + turnaround[a.Lab]
+		}
+		var resultsLabel string
+		if p.timeline != nil && results >= 0 {
+			resultsLabel = p.timeline.Label(results)
+		}
+		index[k] = len(manifest)
+		manifest = append(manifest, Submission{
+			Time:         a.Time,
+			Label:        a.Label,
+			Lab:          a.Lab,
+			Subject:      a.Subject,
+			Matrix:       a.Matrix,
+			Samples:      a.Samples,
+			Results:      results,
+			ResultsLabel: resultsLabel,
+		})
+	}
+
+	slices.SortStableFunc(manifest, func(a, b Submission) int {
+		if c := cmp.Compare(a.Time, b.Time); c != 0 {
+			return c
+		}
+		if (a.Lab == "") != (b.Lab == "") {
+			if a.Lab == "" {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.Lab, b.Lab)
+	})
+	return manifest
+}
+
+// ToManifest formats the lab submission manifest of the solution for printing, grouped by time step and lab.
+func (s *Solution[F]) ToManifest() string {
+	lines := []string{}
+	time, lab := -1, ""
+	for _, sub := range s.Submissions {
+		label := sub.Label
+		if label == "" {
+			label = strconv.Itoa(sub.Time)
+		}
+		if sub.Time != time {
+			lines = append(lines, fmt.Sprintf("Time = %s", label))
+			time, lab = sub.Time, "-"
+		}
+		if sub.Lab != lab {
+			if sub.Lab == "" {
+				lines = append(lines, "    (unassigned)")
+			} else {
+				results := sub.ResultsLabel
+				if results == "" {
+					results = strconv.Itoa(sub.Results)
+				}
+				lines = append(lines, fmt.Sprintf("    %s (results at %s)", sub.Lab, results))
+			}
+			lab = sub.Lab
+		}
+		lines = append(lines, fmt.Sprintf("        %4d x %-10s %s", sub.Samples, sub.Subject, sub.Matrix))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,132 @@
+package isso_test
+
+import (
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabs(t *testing.T) {
+	p := isso.NewProblem(
+		isso.ProblemDef{
+			Matrices: []isso.Matrix{
+				{Name: "fruits", CanReuse: []string{}},
+			},
+			Capacity: []int{0, 500, 500},
+			Requirements: []isso.Requirement{
+				{Subject: "Pest 1", Matrix: "fruits", Samples: 300, Times: []int{1}},
+				{Subject: "Pest 2", Matrix: "fruits", Samples: 100, Times: []int{1}},
+			},
+			Labs: []isso.Lab{
+				{Name: "Cheap", Subjects: []string{"Pest 1", "Pest 2"}, Capacity: []int{0, 200, 200}, Turnaround: 3, Cost: 5},
+				{Name: "Fast", Subjects: []string{"Pest 1"}, Capacity: []int{0, 50, 50}, Turnaround: 1, Cost: 20},
+			},
+		},
+	)
+
+	s := isso.NewSolver(
+		&fitness.TripsAndSamplesEvaluator{},
+		&fitness.TripsThenSamples{},
+	)
+
+	solution, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(solution))
+
+	samples := map[string]int{}
+	for _, a := range solution[0].Actions {
+		samples[a.Subject+"/"+a.Lab] += a.Samples
+	}
+	assert.Equal(t, map[string]int{
+		"Pest 1/Cheap": 100,
+		"Pest 1/Fast":  50,
+		"Pest 1/":      150,
+		"Pest 2/Cheap": 100,
+	}, samples)
+
+	assert.Equal(t, []isso.Submission{
+		{Time: 1, Lab: "Cheap", Subject: "Pest 1", Matrix: "fruits", Samples: 100, Results: 4},
+		{Time: 1, Lab: "Cheap", Subject: "Pest 2", Matrix: "fruits", Samples: 100, Results: 4},
+		{Time: 1, Lab: "Fast", Subject: "Pest 1", Matrix: "fruits", Samples: 50, Results: 2},
+		{Time: 1, Lab: "", Subject: "Pest 1", Matrix: "fruits", Samples: 150, Results: -1},
+	}, solution[0].Submissions)
+
+	manifest := solution[0].ToManifest()
+	assert.Contains(t, manifest, "Cheap (results at 4)")
+	assert.Contains(t, manifest, "(unassigned)")
+	assert.Contains(t, solution[0].ToTable(), "Lab")
+}
+
+func TestLabsOverflow(t *testing.T) {
+	def := isso.ProblemDef{
+		Matrices: []isso.Matrix{
+			{Name: "fruits", CanReuse: []string{}},
+		},
+		Capacity: []int{500, 500},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Matrix: "fruits", Samples: 300, Times: []int{1}},
+			{Subject: "Pest 2", Matrix: "fruits", Samples: 100, Times: []int{1}},
+		},
+	}
+	s := isso.NewSolver(
+		&fitness.TripsAndSamplesEvaluator{},
+		&fitness.TripsThenSamples{},
+	)
+	p := isso.NewProblem(def)
+	expected, ok := s.Solve(&p)
+	assert.True(t, ok)
+
+	// Lab capacity does not constrain the search, samples exceeding it are unassigned.
+	def.Labs = []isso.Lab{
+		{Name: "Small", Subjects: []string{"Pest 1"}, Capacity: []int{0, 200}, Turnaround: 2, Cost: 5},
+		{Name: "Closed", Subjects: []string{"Pest 1", "Pest 2"}, Capacity: []int{0, 0}, Turnaround: 1, Cost: 1},
+	}
+	p = isso.NewProblem(def)
+	solution, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, len(expected), len(solution))
+	assert.Equal(t, expected[0].Fitness, solution[0].Fitness)
+
+	assert.Equal(t, []isso.Submission{
+		{Time: 1, Lab: "Small", Subject: "Pest 1", Matrix: "fruits", Samples: 200, Results: 3},
+		{Time: 1, Lab: "", Subject: "Pest 1", Matrix: "fruits", Samples: 100, Results: -1},
+		{Time: 1, Lab: "", Subject: "Pest 2", Matrix: "fruits", Samples: 100, Results: -1},
+	}, solution[0].Submissions)
+
+	assert.Equal(t, `Time = 1
+    Small (results at 3)
+         200 x Pest 1     fruits
+    (unassigned)
+         100 x Pest 1     fruits
+         100 x Pest 2     fruits`, solution[0].ToManifest())
+}
+
+func TestLabsAssayCosts(t *testing.T) {
+	p := isso.NewProblem(isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}},
+		Capacity: []int{500},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Matrix: "fruits", Samples: 10, Times: []int{0}},
+			{Subject: "Pest 2", Matrix: "fruits", Samples: 10, Times: []int{0}},
+			{Subject: "Pest 3", Matrix: "fruits", Samples: 10, Times: []int{0}},
+		},
+		Labs: []isso.Lab{
+			{Name: "A", Subjects: []string{"Pest 1", "Pest 2"}, Capacity: []int{100}, Cost: 8},
+			{Name: "B", Subjects: []string{"Pest 1"}, Capacity: []int{100}, Cost: 5},
+		},
+		Costs: &isso.Costs{
+			Trip:   100,
+			Assays: map[string]float64{"Pest 2": 3},
+		},
+	})
+
+	// Cheapest lab for Pest 1, explicit cost for Pest 2, no lab and no cost for Pest 3.
+	assert.Equal(t, []float64{5, 3, 0}, p.Costs().Assays)
+
+	s := isso.NewSolver(fitness.NewCostEvaluator(&p), &fitness.LowestCost{})
+	solutions, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, 10*5.0+10*3.0, solutions[0].Fitness.AssayCost)
+}
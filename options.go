@@ -0,0 +1,98 @@
+package isso
+
+// Option configures a [Solver]. See [NewSolver].
+type Option func(*settings)
+
+// settings of a solver.
+type settings struct {
+	tableBytes  int
+	decompose   bool
+	branching   BranchingStrategy
+	constraints []Constraint
+	topK        int
+	tolerance   float64
+}
+
+// WithTranspositionTable enables a transposition table with the given memory limit in bytes.
+//
+// The table stores hashes of explored search states, i.e. of the multiset of actions and the remaining capacity.
+// When an equivalent state is reached through a different order of actions, its subtree is skipped.
+// This requires fitness evaluation to be independent of the order of actions, like for all evaluators in package fitness.
+//
+// Solutions with the same actions in a different order are found only once, which saves their deduplication.
+func WithTranspositionTable(bytes int) Option {
+	return func(s *settings) {
+		s.tableBytes = bytes
+	}
+}
+
+// WithDecomposition enables solving independent sub-problems separately. See [Problem.Decompose].
+//
+// The solutions of the sub-problems are combined, and evaluated and compared as a whole.
+// This requires the objective to be separable between sub-problems, i.e. that the optimal solutions
+// of the problem are combinations of optimal solutions of the sub-problems.
+// This is the case for all evaluators in package fitness, as sub-problems never share time steps.
+func WithDecomposition() Option {
+	return func(s *settings) {
+		s.decompose = true
+	}
+}
+
+// WithBranching sets the branching strategy of the search. The default is [DefaultBranching].
+func WithBranching(strategy BranchingStrategy) Option {
+	return func(s *settings) {
+		s.branching = strategy
+	}
+}
+
+// WithConstraints adds constraints to the constraints of solved problems. See [Constraint].
+//
+// Problems are not decomposed if any of the constraints is not from the library of common constraints,
+// as the solver can't know which requirements it couples. See [WithDecomposition].
+func WithConstraints(constraints ...Constraint) Option {
+	return func(s *settings) {
+		s.constraints = append(s.constraints, constraints...)
+	}
+}
+
+// WithTopK keeps the k best solutions, instead of only the solutions tied for the best fitness.
+//
+// Solutions are sorted by fitness, best first. Among solutions of equal fitness, those found first are kept.
+// Can be combined with [WithTolerance], to keep at most k solutions within the tolerance.
+// Ignored for pareto comparators.
+func WithTopK(k int) Option {
+	return func(s *settings) {
+		s.topK = k
+	}
+}
+
+// WithTolerance keeps all solutions within a relative tolerance of the best fitness,
+// instead of only the solutions tied for the best fitness. E.g., 0.1 keeps solutions within 10% of the best.
+// The comparator must implement [Tolerant], which defines the tolerance for its fitness type.
+//
+// Solutions are sorted by fitness, best first.
+// Can be combined with [WithTopK], to keep at most k solutions within the tolerance.
+// Disables [WithDecomposition]. Ignored for pareto comparators.
+func WithTolerance(tolerance float64) Option {
+	return func(s *settings) {
+		s.tolerance = tolerance
+	}
+}
+
+// Stats are statistics of the last run of a [Solver].
+type Stats struct {
+	Nodes     int // Number of visited search nodes.
+	TableSize int // Number of entries of the transposition table. Zero if disabled.
+	Probes    int // Number of transposition table lookups.
+	Hits      int // Number of transposition table hits, i.e. skipped subtrees.
+	// Number of requirements of each independent sub-problem, if decomposition is enabled.
+	Components []int
+}
+
+// HitRate returns the fraction of transposition table lookups that were hits.
+func (s *Stats) HitRate() float64 {
+	if s.Probes == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Probes)
+}
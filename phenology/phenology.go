@@ -0,0 +1,309 @@
+// Package phenology derives sampling windows of requirements from temperature data.
+//
+// It uses a simple degree-day model. Windows are given in accumulated growing degree-days (GDD),
+// or in BBCH growth stages that are mapped to degree-days by the model.
+package phenology
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mlange-42/isso"
+)
+
+// Temperature record of a single day.
+type Temperature struct {
+	Date time.Time
+	Min  float64
+	Max  float64
+}
+
+// ReadTemperatures reads daily temperatures from CSV.
+//
+// The first row is a header. Required columns are Date, and Min and Max, or Mean.
+// Dates are formatted like "2024-05-01". Days must be in ascending order.
+func ReadTemperatures(r io.Reader, delim rune) ([]Temperature, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delim
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty temperature file")
+	}
+
+	columns := map[string]int{}
+	for i, c := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(c))] = i
+	}
+	dateCol, ok := columns["date"]
+	if !ok {
+		return nil, fmt.Errorf("missing column 'Date' in temperature file")
+	}
+	minCol, hasMin := columns["min"]
+	maxCol, hasMax := columns["max"]
+	meanCol, hasMean := columns["mean"]
+	if !(hasMin && hasMax) && !hasMean {
+		return nil, fmt.Errorf("temperature file requires columns 'Min' and 'Max', or 'Mean'")
+	}
+
+	parse := func(row []string, col int, line int) (float64, error) {
+		v, err := strconv.ParseFloat(strings.TrimSpace(row[col]), 64)
+		if err != nil {
+			return 0, fmt.Errorf("line %d: invalid temperature '%s'", line, row[col])
+		}
+		return v, nil
+	}
+
+	temps := make([]Temperature, 0, len(records)-1)
+	for i, row := range records[1:] {
+		line := i + 2
+		date, err := time.Parse(isso.DateFormat, strings.TrimSpace(row[dateCol]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date '%s'", line, row[dateCol])
+		}
+		if len(temps) > 0 && !date.After(temps[len(temps)-1].Date) {
+			return nil, fmt.Errorf("line %d: dates must be in ascending order", line)
+		}
+
+		t := Temperature{Date: date}
+		if hasMin && hasMax {
+			if t.Min, err = parse(row, minCol, line); err != nil {
+				return nil, err
+			}
+			if t.Max, err = parse(row, maxCol, line); err != nil {
+				return nil, err
+			}
+		} else {
+			if t.Min, err = parse(row, meanCol, line); err != nil {
+				return nil, err
+			}
+			t.Max = t.Min
+		}
+		temps = append(temps, t)
+	}
+	return temps, nil
+}
+
+// Stage maps a BBCH growth stage to the accumulated degree-days at which it is reached.
+type Stage struct {
+	BBCH int
+	GDD  float64
+}
+
+// Model is a simple degree-day model, using the averaging method.
+type Model struct {
+	Base   float64 // Base temperature, below which no development happens.
+	Upper  float64 // Upper threshold temperature, above which development does not increase. Zero for none.
+	Stages []Stage // Degree-day thresholds of BBCH growth stages.
+}
+
+// DegreeDays calculates the growing degree-days of a single day.
+func (m *Model) DegreeDays(t Temperature) float64 {
+	tMin, tMax := t.Min, t.Max
+	if m.Upper > 0 {
+		tMin = min(tMin, m.Upper)
+		tMax = min(tMax, m.Upper)
+	}
+	return max((tMin+tMax)/2-m.Base, 0)
+}
+
+// Accumulate calculates accumulated degree-days at the end of each day.
+func (m *Model) Accumulate(temps []Temperature) []float64 {
+	gdd := make([]float64, len(temps))
+	sum := 0.0
+	for i, t := range temps {
+		sum += m.DegreeDays(t)
+		gdd[i] = sum
+	}
+	return gdd
+}
+
+// StageGDD returns the accumulated degree-days at which a BBCH growth stage is reached.
+func (m *Model) StageGDD(bbch int) (float64, error) {
+	for _, s := range m.Stages {
+		if s.BBCH == bbch {
+			return s.GDD, nil
+		}
+	}
+	return 0, fmt.Errorf("BBCH stage %d not defined in model", bbch)
+}
+
+// Window is the sampling window of a requirement, in terms of phenology.
+//
+// Either GDD or BBCH should be given, each with two entries for the start and end of the window.
+type Window struct {
+	Subject string
+	GDD     []float64 // Window in accumulated degree-days.
+	BBCH    []int     // Window in BBCH growth stages.
+}
+
+// Config is a phenology definition for a problem.
+type Config struct {
+	Model   Model
+	Windows []Window
+}
+
+// Derived is a sampling window derived from temperature data.
+type Derived struct {
+	Subject  string
+	From     float64 // Start of the window in accumulated degree-days.
+	To       float64 // End of the window in accumulated degree-days.
+	FirstDay time.Time
+	LastDay  time.Time
+	Times    []int
+}
+
+// Derive calculates the sampling windows from temperature data.
+//
+// Days are mapped to time steps using the problem's timeline, which must have a start and a step.
+// Days before the start of the timeline are ignored.
+// Without timeline, the first day of the temperature data is time step 0, and each day is a time step.
+// Temperature data must cover consecutive days, without gaps.
+func (c *Config) Derive(timeline *isso.Timeline, temps []Temperature) ([]Derived, error) {
+	if len(temps) == 0 {
+		return nil, fmt.Errorf("no temperature data")
+	}
+	for i := 1; i < len(temps); i++ {
+		if !temps[i].Date.Equal(temps[i-1].Date.AddDate(0, 0, 1)) {
+			return nil, fmt.Errorf("temperature data is missing days between %s and %s",
+				temps[i-1].Date.Format(isso.DateFormat), temps[i].Date.Format(isso.DateFormat))
+		}
+	}
+	start := temps[0].Date
+	if timeline != nil {
+		if len(timeline.Labels) > 0 {
+			return nil, fmt.Errorf("phenology windows can't be mapped to a timeline with labels; use start and step")
+		}
+		var err error
+		if start, err = time.Parse(isso.DateFormat, timeline.Start); err != nil {
+			return nil, fmt.Errorf("invalid timeline start: %s", err.Error())
+		}
+	}
+	gdd := c.Model.Accumulate(temps)
+
+	derived := make([]Derived, 0, len(c.Windows))
+	for _, w := range c.Windows {
+		from, to, err := c.bounds(&w)
+		if err != nil {
+			return nil, err
+		}
+
+		d := Derived{Subject: w.Subject, From: from, To: to, Times: []int{}}
+		first, last := -1, -1
+		before := false
+		for i, g := range gdd {
+			if g < from || g > to {
+				continue
+			}
+			if temps[i].Date.Before(start) {
+				before = true
+				continue
+			}
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+		if first < 0 {
+			if before {
+				return nil, fmt.Errorf("window of subject '%s' (%.1f to %.1f GDD) is before the timeline start %s", w.Subject, from, to, timeline.Start)
+			}
+			return nil, fmt.Errorf("window of subject '%s' (%.1f to %.1f GDD) not covered by temperature data", w.Subject, from, to)
+		}
+		d.FirstDay, d.LastDay = temps[first].Date, temps[last].Date
+
+		for i := first; i <= last; i++ {
+			var step int
+			if timeline == nil {
+				step = int(temps[i].Date.Sub(start).Hours() / 24)
+			} else {
+				step, err = timeline.Index(temps[i].Date.Format(isso.DateFormat))
+				if err != nil {
+					return nil, err
+				}
+			}
+			if len(d.Times) == 0 || d.Times[len(d.Times)-1] != step {
+				d.Times = append(d.Times, step)
+			}
+		}
+		derived = append(derived, d)
+	}
+	return derived, nil
+}
+
+// bounds returns the start and end of a window in accumulated degree-days.
+func (c *Config) bounds(w *Window) (float64, float64, error) {
+	if len(w.GDD) > 0 {
+		if len(w.GDD) != 2 || len(w.BBCH) > 0 {
+			return 0, 0, fmt.Errorf("window of subject '%s' requires either two GDD entries or two BBCH entries", w.Subject)
+		}
+		if w.GDD[0] > w.GDD[1] {
+			return 0, 0, fmt.Errorf("window of subject '%s' ends before it starts (%.1f to %.1f GDD)", w.Subject, w.GDD[0], w.GDD[1])
+		}
+		return w.GDD[0], w.GDD[1], nil
+	}
+	if len(w.BBCH) != 2 {
+		return 0, 0, fmt.Errorf("window of subject '%s' requires either two GDD entries or two BBCH entries", w.Subject)
+	}
+	if w.BBCH[0] > w.BBCH[1] {
+		return 0, 0, fmt.Errorf("window of subject '%s' ends before it starts (BBCH %d to %d)", w.Subject, w.BBCH[0], w.BBCH[1])
+	}
+	from, err := c.Model.StageGDD(w.BBCH[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := c.Model.StageGDD(w.BBCH[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return from, to, nil
+}
+
+// Apply derives sampling windows from temperature data and sets them as the times of the problem's requirements.
+// Requirements without a phenology window are not changed.
+//
+// Times are clipped to the problem's time steps, see [isso.ProblemDef.NumTimes].
+// It is an error if no time step of a window remains.
+func (c *Config) Apply(problem *isso.ProblemDef, temps []Temperature) ([]Derived, error) {
+	derived, err := c.Derive(problem.Timeline, temps)
+	if err != nil {
+		return nil, err
+	}
+
+	if numTimes := problem.NumTimes(); numTimes > 0 {
+		for i := range derived {
+			d := &derived[i]
+			d.Times = slices.DeleteFunc(d.Times, func(t int) bool { return t >= numTimes })
+			if len(d.Times) == 0 {
+				return nil, fmt.Errorf("window of subject '%s' (%s to %s) is after the last time step %s",
+					d.Subject, d.FirstDay.Format(isso.DateFormat), d.LastDay.Format(isso.DateFormat),
+					problem.Timeline.Label(numTimes-1))
+			}
+		}
+	}
+
+	for _, d := range derived {
+		found := false
+		for i := range problem.Requirements {
+			req := &problem.Requirements[i]
+			if req.Subject == d.Subject {
+				req.Times = d.Times
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("phenology window for unknown subject '%s'", d.Subject)
+		}
+	}
+	return derived, nil
+}
@@ -0,0 +1,160 @@
+package phenology_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/phenology"
+	"github.com/stretchr/testify/assert"
+)
+
+const temperatures = `Date,Min,Max
+2024-05-01,5,15
+2024-05-02,10,20
+2024-05-03,15,25
+2024-05-04,15,25
+2024-05-05,20,30
+2024-05-06,20,40
+`
+
+func TestReadTemperatures(t *testing.T) {
+	temps, err := phenology.ReadTemperatures(strings.NewReader(temperatures), ',')
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(temps))
+	assert.Equal(t, 10.0, temps[1].Min)
+	assert.Equal(t, 20.0, temps[1].Max)
+
+	temps, err = phenology.ReadTemperatures(strings.NewReader("Date;Mean\n2024-05-01;12.5\n"), ';')
+	assert.Nil(t, err)
+	assert.Equal(t, 12.5, temps[0].Min)
+	assert.Equal(t, 12.5, temps[0].Max)
+
+	_, err = phenology.ReadTemperatures(strings.NewReader("Date,Min\n2024-05-01,12.5\n"), ',')
+	assert.NotNil(t, err)
+
+	_, err = phenology.ReadTemperatures(strings.NewReader("Date,Mean\n2024-05-02,12.5\n2024-05-01,12.5\n"), ',')
+	assert.NotNil(t, err)
+
+	_, err = phenology.ReadTemperatures(strings.NewReader("Date,Mean\n2024-05-01,warm\n"), ',')
+	assert.NotNil(t, err)
+}
+
+func TestModel(t *testing.T) {
+	temps, err := phenology.ReadTemperatures(strings.NewReader(temperatures), ',')
+	assert.Nil(t, err)
+
+	model := phenology.Model{Base: 10, Upper: 30}
+	assert.Equal(t, 0.0, model.DegreeDays(temps[0]))
+	assert.Equal(t, 15.0, model.DegreeDays(temps[5]))
+
+	assert.Equal(t, []float64{0, 5, 15, 25, 40, 55}, model.Accumulate(temps))
+}
+
+func TestApply(t *testing.T) {
+	temps, err := phenology.ReadTemperatures(strings.NewReader(temperatures), ',')
+	assert.Nil(t, err)
+
+	config := phenology.Config{
+		Model: phenology.Model{
+			Base: 10,
+			Stages: []phenology.Stage{
+				{BBCH: 51, GDD: 10},
+				{BBCH: 61, GDD: 30},
+			},
+		},
+		Windows: []phenology.Window{
+			{Subject: "Pest 1", GDD: []float64{5, 25}},
+			{Subject: "Pest 2", BBCH: []int{51, 61}},
+		},
+	}
+
+	problem := isso.ProblemDef{
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1"},
+			{Subject: "Pest 2"},
+			{Subject: "Pest 3", Times: []int{0}},
+		},
+	}
+	derived, err := config.Apply(&problem, temps)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(derived))
+	assert.Equal(t, []int{1, 2, 3}, problem.Requirements[0].Times)
+	assert.Equal(t, []int{2, 3}, problem.Requirements[1].Times)
+	assert.Equal(t, []int{0}, problem.Requirements[2].Times)
+
+	problem.Capacity = []int{100, 100, 100}
+	derived, err = config.Apply(&problem, temps)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, derived[0].Times)
+	assert.Equal(t, []int{1, 2}, problem.Requirements[0].Times)
+	assert.Equal(t, []int{2}, problem.Requirements[1].Times)
+
+	problem.Capacity = []int{100, 100}
+	_, err = config.Apply(&problem, temps)
+	assert.Equal(t, "window of subject 'Pest 2' (2024-05-03 to 2024-05-04) is after the last time step 1", err.Error())
+	problem.Capacity = nil
+
+	problem.Timeline = &isso.Timeline{Start: "2024-04-29", Step: "week"}
+	_, err = config.Apply(&problem, temps)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0}, problem.Requirements[0].Times)
+	assert.Equal(t, []int{0}, problem.Requirements[1].Times)
+
+	config.Windows = append(config.Windows, phenology.Window{Subject: "Pest 4", GDD: []float64{100, 200}})
+	_, err = config.Apply(&problem, temps)
+	assert.NotNil(t, err)
+
+	config.Windows = []phenology.Window{{Subject: "Pest 1", BBCH: []int{51, 71}}}
+	_, err = config.Apply(&problem, temps)
+	assert.NotNil(t, err)
+
+	config.Windows = []phenology.Window{{Subject: "Pest 5", GDD: []float64{0, 10}}}
+	_, err = config.Apply(&problem, temps)
+	assert.NotNil(t, err)
+}
+
+func TestDerive(t *testing.T) {
+	temps, err := phenology.ReadTemperatures(strings.NewReader(temperatures), ',')
+	assert.Nil(t, err)
+
+	config := phenology.Config{
+		Model: phenology.Model{
+			Base: 10,
+			Stages: []phenology.Stage{
+				{BBCH: 51, GDD: 10},
+				{BBCH: 61, GDD: 30},
+			},
+		},
+		Windows: []phenology.Window{
+			{Subject: "Pest 1", GDD: []float64{5, 25}},
+		},
+	}
+
+	derived, err := config.Derive(&isso.Timeline{Start: "2024-05-03", Step: "day"}, temps)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1}, derived[0].Times)
+	assert.Equal(t, "2024-05-03", derived[0].FirstDay.Format(isso.DateFormat))
+	assert.Equal(t, "2024-05-04", derived[0].LastDay.Format(isso.DateFormat))
+
+	config.Windows = []phenology.Window{{Subject: "Pest 1", GDD: []float64{0, 5}}}
+	_, err = config.Derive(&isso.Timeline{Start: "2024-05-03", Step: "day"}, temps)
+	assert.Equal(t, "window of subject 'Pest 1' (0.0 to 5.0 GDD) is before the timeline start 2024-05-03", err.Error())
+
+	_, err = config.Derive(&isso.Timeline{Labels: []string{"May", "June"}}, temps)
+	assert.Equal(t, "phenology windows can't be mapped to a timeline with labels; use start and step", err.Error())
+
+	config.Windows = []phenology.Window{{Subject: "Pest 1", GDD: []float64{25, 5}}}
+	_, err = config.Derive(nil, temps)
+	assert.Equal(t, "window of subject 'Pest 1' ends before it starts (25.0 to 5.0 GDD)", err.Error())
+
+	config.Windows = []phenology.Window{{Subject: "Pest 1", BBCH: []int{61, 51}}}
+	_, err = config.Derive(nil, temps)
+	assert.Equal(t, "window of subject 'Pest 1' ends before it starts (BBCH 61 to 51)", err.Error())
+
+	config.Windows = []phenology.Window{{Subject: "Pest 1", GDD: []float64{5, 25}}}
+	gap := append(slices.Clone(temps[:2]), temps[3:]...)
+	_, err = config.Derive(nil, gap)
+	assert.Equal(t, "temperature data is missing days between 2024-05-02 and 2024-05-04", err.Error())
+}
@@ -0,0 +1,46 @@
+package isso
+
+import (
+	"slices"
+)
+
+// Replan derives a problem for iterative re-planning from a previous problem and solution.
+//
+// All own samples planned in the solution before the given time are assumed to be collected as planned,
+// except for subjects and times covered by the actuals or by the problem's fixed actions.
+// Actuals replace planned samples and are used to feed back what actually happened in the field, including shortfalls.
+// The returned problem has the current time set to the given time, and all past actions as fixed actions.
+func Replan[F any](problem ProblemDef, solution Solution[F], actuals []Action, time int) ProblemDef {
+	type key struct {
+		Subject string
+		Time    int
+	}
+	known := map[key]bool{}
+	for _, a := range problem.FixedActions {
+		known[key{a.Subject, a.Time}] = true
+	}
+	for _, a := range actuals {
+		known[key{a.Subject, a.Time}] = true
+	}
+
+	fixed := slices.Clone(problem.FixedActions)
+	for _, a := range solution.Actions {
+		if a.Reuse != "" || a.Time < problem.CurrentTime || a.Time >= time {
+			continue
+		}
+		if known[key{a.Subject, a.Time}] {
+			continue
+		}
+		fixed = append(fixed, a)
+	}
+	for _, a := range actuals {
+		if a.Reuse != "" {
+			continue
+		}
+		fixed = append(fixed, a)
+	}
+
+	problem.FixedActions = fixed
+	problem.CurrentTime = time
+	return problem
+}
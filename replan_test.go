@@ -0,0 +1,75 @@
+package isso_test
+
+import (
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplan(t *testing.T) {
+	problem := isso.ProblemDef{
+		Matrices: []isso.Matrix{
+			{Name: "fruits", CanReuse: []string{}},
+		},
+		Capacity: []int{100, 100, 100, 100},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Matrix: "fruits", Samples: 150, Times: []int{0, 1, 2}},
+		},
+	}
+	solution := isso.Solution[int]{
+		Actions: []isso.Action{
+			{Subject: "Pest 1", Matrix: "fruits", Time: 0, Samples: 100},
+			{Subject: "Pest 1", Matrix: "fruits", Time: 1, Samples: 50},
+		},
+	}
+	actuals := []isso.Action{
+		{Subject: "Pest 1", Time: 1, Samples: 20},
+	}
+
+	replan := isso.Replan(problem, solution, actuals, 2)
+	assert.Equal(t, 2, replan.CurrentTime)
+	assert.Equal(t, []isso.Action{
+		{Subject: "Pest 1", Matrix: "fruits", Time: 0, Samples: 100},
+		{Subject: "Pest 1", Time: 1, Samples: 20},
+	}, replan.FixedActions)
+
+	p := isso.NewProblem(replan)
+	s := isso.NewSolver(
+		&fitness.TripsAndSamplesEvaluator{},
+		&fitness.TripsThenSamples{},
+	)
+	solutions, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(solutions))
+	assert.Equal(t, fitness.TripsAndSamplesFitness{Trips: 3, Samples: 150}, solutions[0].Fitness)
+
+	last := solutions[0].Actions[len(solutions[0].Actions)-1]
+	assert.Equal(t, 2, last.Time)
+	assert.Equal(t, 30, last.Samples)
+}
+
+func TestReplanInfeasible(t *testing.T) {
+	problem := isso.ProblemDef{
+		Matrices: []isso.Matrix{
+			{Name: "fruits", CanReuse: []string{}},
+		},
+		Capacity: []int{100, 100, 100, 100},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Matrix: "fruits", Samples: 150, Times: []int{0, 1}},
+		},
+		FixedActions: []isso.Action{
+			{Subject: "Pest 1", Time: 0, Samples: 100},
+		},
+		CurrentTime: 2,
+	}
+
+	p := isso.NewProblem(problem)
+	s := isso.NewSolver(
+		&fitness.TripsAndSamplesEvaluator{},
+		&fitness.TripsThenSamples{},
+	)
+	_, ok := s.Solve(&p)
+	assert.False(t, ok)
+}
@@ -0,0 +1,147 @@
+// Package route solves the tours of sampling trips, as small travelling salesman problems.
+//
+// Tours start and end at the depot, which is node 0 of the duration matrix.
+package route
+
+import (
+	"math"
+)
+
+// ExactLimit is the maximum number of nodes for which tours are solved exactly.
+// Larger tours are solved heuristically, using nearest neighbour construction and 2-opt improvement.
+const ExactLimit = 10
+
+// Tour calculates a tour that starts and ends at the depot (node 0), and visits all given nodes.
+// Returns the order of the visited nodes, excluding the depot, and the tour's duration.
+//
+// Durations may be asymmetric.
+func Tour(durations [][]float64, nodes []int) ([]int, float64) {
+	if len(nodes) == 0 {
+		return []int{}, 0
+	}
+	if len(nodes) <= ExactLimit {
+		return exact(durations, nodes)
+	}
+	return heuristic(durations, nodes)
+}
+
+// Duration calculates the duration of a tour in the given order, starting and ending at the depot.
+func Duration(durations [][]float64, order []int) float64 {
+	if len(order) == 0 {
+		return 0
+	}
+	d := durations[0][order[0]]
+	for i := 1; i < len(order); i++ {
+		d += durations[order[i-1]][order[i]]
+	}
+	return d + durations[order[len(order)-1]][0]
+}
+
+// exact solves a tour using the Held-Karp dynamic programming algorithm.
+func exact(durations [][]float64, nodes []int) ([]int, float64) {
+	n := len(nodes)
+	numSets := 1 << n
+
+	// cost[set*n+last] is the minimum duration from the depot, visiting set and ending at last.
+	cost := make([]float64, numSets*n)
+	parent := make([]int, numSets*n)
+	for i := range cost {
+		cost[i] = math.Inf(1)
+	}
+	for i := 0; i < n; i++ {
+		cost[(1<<i)*n+i] = durations[0][nodes[i]]
+		parent[(1<<i)*n+i] = -1
+	}
+
+	for set := 1; set < numSets; set++ {
+		for last := 0; last < n; last++ {
+			if set&(1<<last) == 0 {
+				continue
+			}
+			c := cost[set*n+last]
+			if math.IsInf(c, 1) {
+				continue
+			}
+			for next := 0; next < n; next++ {
+				if set&(1<<next) != 0 {
+					continue
+				}
+				nextSet := set | (1 << next)
+				nc := c + durations[nodes[last]][nodes[next]]
+				if nc < cost[nextSet*n+next] {
+					cost[nextSet*n+next] = nc
+					parent[nextSet*n+next] = last
+				}
+			}
+		}
+	}
+
+	full := numSets - 1
+	best, bestLast := math.Inf(1), 0
+	for last := 0; last < n; last++ {
+		c := cost[full*n+last] + durations[nodes[last]][0]
+		if c < best {
+			best, bestLast = c, last
+		}
+	}
+
+	order := make([]int, n)
+	set, last := full, bestLast
+	for i := n - 1; i >= 0; i-- {
+		order[i] = nodes[last]
+		prev := parent[set*n+last]
+		set &^= 1 << last
+		last = prev
+	}
+	return order, best
+}
+
+// heuristic solves a tour using nearest neighbour construction, followed by 2-opt improvement.
+func heuristic(durations [][]float64, nodes []int) ([]int, float64) {
+	n := len(nodes)
+	order := make([]int, 0, n)
+	visited := make([]bool, n)
+
+	current := 0
+	for len(order) < n {
+		best, bestIdx := math.Inf(1), -1
+		for i, node := range nodes {
+			if visited[i] {
+				continue
+			}
+			if d := durations[current][node]; d < best {
+				best, bestIdx = d, i
+			}
+		}
+		visited[bestIdx] = true
+		current = nodes[bestIdx]
+		order = append(order, current)
+	}
+
+	best := Duration(durations, order)
+	improved := true
+	for improved {
+		improved = false
+		for i := 0; i < n-1; i++ {
+			for j := i + 1; j < n; j++ {
+				reverse(order, i, j)
+				if d := Duration(durations, order); d < best-1e-9 {
+					best = d
+					improved = true
+				} else {
+					reverse(order, i, j)
+				}
+			}
+		}
+	}
+	return order, best
+}
+
+// reverse reverses the elements of a slice between i and j, inclusive.
+func reverse(s []int, i, j int) {
+	for i < j {
+		s[i], s[j] = s[j], s[i]
+		i++
+		j--
+	}
+}
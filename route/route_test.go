@@ -0,0 +1,73 @@
+package route_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/mlange-42/isso/route"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTour(t *testing.T) {
+	durations := [][]float64{
+		{0, 1, 2, 1},
+		{1, 0, 1, 2},
+		{2, 1, 0, 1},
+		{1, 2, 1, 0},
+	}
+
+	order, d := route.Tour(durations, []int{})
+	assert.Equal(t, []int{}, order)
+	assert.Equal(t, 0.0, d)
+
+	order, d = route.Tour(durations, []int{2})
+	assert.Equal(t, []int{2}, order)
+	assert.Equal(t, 4.0, d)
+
+	order, d = route.Tour(durations, []int{3, 2, 1})
+	assert.Equal(t, 4.0, d)
+	assert.Equal(t, d, route.Duration(durations, order))
+	assert.Equal(t, 3, len(order))
+}
+
+func TestTourAsymmetric(t *testing.T) {
+	durations := [][]float64{
+		{0, 1, 5},
+		{5, 0, 1},
+		{1, 5, 0},
+	}
+	order, d := route.Tour(durations, []int{1, 2})
+	assert.Equal(t, []int{1, 2}, order)
+	assert.Equal(t, 3.0, d)
+}
+
+func TestTourHeuristic(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+
+	// Points on a circle, where the optimal tour follows the circle.
+	n := 16
+	points := make([][2]float64, n)
+	for i := range points {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		points[i] = [2]float64{math.Cos(angle), math.Sin(angle)}
+	}
+	durations := make([][]float64, n)
+	for i := range durations {
+		durations[i] = make([]float64, n)
+		for j := range durations[i] {
+			durations[i][j] = math.Hypot(points[i][0]-points[j][0], points[i][1]-points[j][1])
+		}
+	}
+
+	nodes := make([]int, n-1)
+	for i := range nodes {
+		nodes[i] = i + 1
+	}
+	rng.Shuffle(len(nodes), func(i, j int) { nodes[i], nodes[j] = nodes[j], nodes[i] })
+
+	order, d := route.Tour(durations, nodes)
+	assert.Equal(t, n-1, len(order))
+	assert.InDelta(t, float64(n)*durations[0][1], d, 1e-9)
+	assert.InDelta(t, d, route.Duration(durations, order), 1e-9)
+}
@@ -0,0 +1,94 @@
+package isso
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SchemaVersion is the version of the JSON format of solution files. See [SolutionFile].
+// It is incremented for changes that break reading files of earlier versions.
+//
+// JSON Schemas of problem and solution files are in folder schema of the repository.
+const SchemaVersion = 1
+
+// SolverSettings describe the settings a solution file was created with, for reproducibility.
+// They are informational, and are not used when reading solutions.
+type SolverSettings struct {
+	Fitness      string  // Name of the fitness function, like "trips", "route" or "cost".
+	Pareto       bool    // Whether pareto optimization was used.
+	CombineSites bool    // Whether sites visited at the same time were counted as a single trip.
+	Branching    string  // Name of the branching strategy.
+	TableBytes   int     // Memory limit of the transposition table in bytes. Zero if disabled.
+	Decompose    bool    // Whether independent sub-problems were solved separately.
+	TopK         int     // Number of best solutions kept. Zero if disabled.
+	Tolerance    float64 // Relative tolerance of kept solutions. Zero if disabled.
+}
+
+// SolutionFile is the versioned JSON envelope of solution files.
+// See [WriteSolutions] and [ReadSolutions].
+//
+// It contains the problem definition, so that solutions can be verified and re-planned from the file alone.
+type SolutionFile[F any] struct {
+	Version   int // Schema version. See [SchemaVersion].
+	Problem   ProblemDef
+	Settings  SolverSettings
+	Stats     Stats
+	Solutions []Solution[F]
+}
+
+// WriteSolutions writes a solution file as indented JSON, with the current [SchemaVersion].
+func WriteSolutions[F any](w io.Writer, file *SolutionFile[F]) error {
+	f := *file
+	f.Version = SchemaVersion
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	return enc.Encode(&f)
+}
+
+// ReadSolutions reads a solution file, as written with [WriteSolutions].
+// Use [json.RawMessage] as fitness type for reading solutions of any fitness type.
+//
+// Files of the format before versioning, with a list of solutions followed by the problem definition,
+// are read as version 0, without settings and stats.
+// Returns an error for files of a later schema version than [SchemaVersion].
+func ReadSolutions[F any](r io.Reader) (SolutionFile[F], error) {
+	file := SolutionFile[F]{}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return file, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		return readLegacySolutions[F](data)
+	}
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, err
+	}
+	if file.Version < 1 {
+		return file, fmt.Errorf("solution file has no schema version")
+	}
+	if file.Version > SchemaVersion {
+		return file, fmt.Errorf("solution file has schema version %d, but only versions up to %d are supported",
+			file.Version, SchemaVersion)
+	}
+	return file, nil
+}
+
+// readLegacySolutions reads a solution file of the format before versioning.
+func readLegacySolutions[F any](data []byte) (SolutionFile[F], error) {
+	file := SolutionFile[F]{}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&file.Solutions); err != nil {
+		return file, err
+	}
+	if err := dec.Decode(&file.Problem); err != nil {
+		return file, fmt.Errorf("reading problem from solution file: %s", err.Error())
+	}
+	return file, nil
+}
@@ -0,0 +1,271 @@
+package isso_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteReadSolutions(t *testing.T) {
+	def := generateProblem(3, 5, 8, 2)
+	p := isso.NewProblem(def)
+	s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{},
+		isso.WithTopK(3))
+	solutions, ok := s.Solve(&p)
+	assert.True(t, ok)
+
+	file := isso.SolutionFile[fitness.TripsAndSamplesFitness]{
+		Problem:   def,
+		Settings:  isso.SolverSettings{Fitness: "trips", TopK: 3},
+		Stats:     s.Stats(),
+		Solutions: solutions,
+	}
+	b := bytes.Buffer{}
+	assert.Nil(t, isso.WriteSolutions(&b, &file))
+	assert.Equal(t, 0, file.Version)
+
+	read, err := isso.ReadSolutions[fitness.TripsAndSamplesFitness](bytes.NewReader(b.Bytes()))
+	assert.Nil(t, err)
+	file.Version = isso.SchemaVersion
+	assert.Equal(t, file, read)
+
+	raw, err := isso.ReadSolutions[json.RawMessage](bytes.NewReader(b.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, len(solutions), len(raw.Solutions))
+	fit, err := json.Marshal(solutions[0].Fitness)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(fit), string(raw.Solutions[0].Fitness))
+
+	_, err = isso.ReadSolutions[json.RawMessage](strings.NewReader(`{"Version": 2, "Solutions": []}`))
+	assert.NotNil(t, err)
+	_, err = isso.ReadSolutions[json.RawMessage](strings.NewReader(`{"Solutions": []}`))
+	assert.NotNil(t, err)
+	_, err = isso.ReadSolutions[json.RawMessage](strings.NewReader(`{"Version": 1`))
+	assert.NotNil(t, err)
+}
+
+func TestReadLegacySolutions(t *testing.T) {
+	f, err := os.Open("data/replan/solution.json")
+	assert.Nil(t, err)
+	defer f.Close()
+
+	file, err := isso.ReadSolutions[fitness.TripsAndSamplesFitness](f)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, file.Version)
+	assert.Equal(t, 1, len(file.Solutions))
+	assert.Equal(t, fitness.TripsAndSamplesFitness{Trips: 5, Samples: 1826}, file.Solutions[0].Fitness)
+	assert.Equal(t, 4, len(file.Problem.Matrices))
+
+	_, err = isso.ReadSolutions[json.RawMessage](strings.NewReader(`[]`))
+	assert.NotNil(t, err)
+}
+
+func TestSchemaFields(t *testing.T) {
+	problem := readSchema(t, "problem.schema.json")
+	solution := readSchema(t, "solution.schema.json")
+
+	types := []struct {
+		schema map[string]any
+		def    string
+		tp     reflect.Type
+	}{
+		{problem, "", reflect.TypeFor[isso.ProblemDef]()},
+		{problem, "Matrix", reflect.TypeFor[isso.Matrix]()},
+		{problem, "Requirement", reflect.TypeFor[isso.Requirement]()},
+		{problem, "Site", reflect.TypeFor[isso.Site]()},
+		{problem, "Action", reflect.TypeFor[isso.Action]()},
+		{problem, "Timeline", reflect.TypeFor[isso.Timeline]()},
+		{problem, "Travel", reflect.TypeFor[isso.Travel]()},
+		{problem, "Lab", reflect.TypeFor[isso.Lab]()},
+		{problem, "Costs", reflect.TypeFor[isso.Costs]()},
+		{problem, "ConstraintDef", reflect.TypeFor[isso.ConstraintDef]()},
+		{solution, "", reflect.TypeFor[isso.SolutionFile[json.RawMessage]]()},
+		{solution, "SolverSettings", reflect.TypeFor[isso.SolverSettings]()},
+		{solution, "Stats", reflect.TypeFor[isso.Stats]()},
+		{solution, "Solution", reflect.TypeFor[isso.Solution[json.RawMessage]]()},
+		{solution, "Tour", reflect.TypeFor[isso.Tour]()},
+		{solution, "Submission", reflect.TypeFor[isso.Submission]()},
+	}
+	for _, tt := range types {
+		schema := tt.schema
+		if tt.def != "" {
+			schema = tt.schema["$defs"].(map[string]any)[tt.def].(map[string]any)
+		}
+		properties := []string{}
+		for name := range schema["properties"].(map[string]any) {
+			properties = append(properties, name)
+		}
+		fields := []string{}
+		for i := 0; i < tt.tp.NumField(); i++ {
+			if tt.tp.Field(i).IsExported() {
+				fields = append(fields, tt.tp.Field(i).Name)
+			}
+		}
+		slices.Sort(properties)
+		slices.Sort(fields)
+		assert.Equal(t, fields, properties, tt.tp.Name())
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schemas := map[string]map[string]any{
+		"problem.schema.json":  readSchema(t, "problem.schema.json"),
+		"solution.schema.json": readSchema(t, "solution.schema.json"),
+	}
+
+	files, err := filepath.Glob("data/*.json")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, files)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		assert.Nil(t, err)
+		var value any
+		assert.Nil(t, json.Unmarshal(data, &value))
+		assert.Nil(t, validate(schemas, "problem.schema.json", schemas["problem.schema.json"], value, ""), file)
+	}
+
+	p := isso.NewProblem(generateProblem(3, 5, 8, 2))
+	s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{})
+	solutions, _ := s.Solve(&p)
+	b := bytes.Buffer{}
+	assert.Nil(t, isso.WriteSolutions(&b, &isso.SolutionFile[fitness.TripsAndSamplesFitness]{
+		Problem:   generateProblem(3, 5, 8, 2),
+		Stats:     s.Stats(),
+		Solutions: solutions,
+	}))
+	var value any
+	assert.Nil(t, json.Unmarshal(b.Bytes(), &value))
+	assert.Nil(t, validate(schemas, "solution.schema.json", schemas["solution.schema.json"], value, ""))
+
+	value.(map[string]any)["Foo"] = 1
+	assert.NotNil(t, validate(schemas, "solution.schema.json", schemas["solution.schema.json"], value, ""))
+}
+
+func readSchema(t *testing.T, name string) map[string]any {
+	data, err := os.ReadFile(filepath.Join("schema", name))
+	assert.Nil(t, err)
+	schema := map[string]any{}
+	assert.Nil(t, json.Unmarshal(data, &schema))
+	return schema
+}
+
+// validate validates a JSON value against the subset of JSON Schema used by the schemas of this repository.
+func validate(schemas map[string]map[string]any, file string, schema map[string]any, value any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		refFile, pointer, _ := strings.Cut(ref, "#")
+		if refFile != "" {
+			file = refFile
+		}
+		target := schemas[file]
+		for _, part := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+			if part != "" {
+				target = target[part].(map[string]any)
+			}
+		}
+		return validate(schemas, file, target, value, path)
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		for _, s := range anyOf {
+			if validate(schemas, file, s.(map[string]any), value, path) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: no alternative matches", path)
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+	}
+	if c, ok := schema["const"]; ok && c != value {
+		return fmt.Errorf("%s: %v is not %v", path, value, c)
+	}
+	if tp, ok := schema["type"]; ok {
+		types := []any{tp}
+		if list, ok := tp.([]any); ok {
+			types = list
+		}
+		if !slices.ContainsFunc(types, func(tp any) bool { return hasType(value, tp.(string)) }) {
+			return fmt.Errorf("%s: %v is not of type %v", path, value, tp)
+		}
+	}
+	if minimum, ok := schema["minimum"].(float64); ok {
+		if v, ok := value.(float64); ok && v < minimum {
+			return fmt.Errorf("%s: %v is less than %v", path, v, minimum)
+		}
+	}
+
+	switch v := value.(type) {
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validate(schemas, file, items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				if _, ok := v[r.(string)]; !ok {
+					return fmt.Errorf("%s: missing property %s", path, r)
+				}
+			}
+		}
+		for key, item := range v {
+			if s, ok := properties[key]; ok {
+				if err := validate(schemas, file, s.(map[string]any), item, path+"."+key); err != nil {
+					return err
+				}
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return fmt.Errorf("%s: unknown property %s", path, key)
+				}
+			case map[string]any:
+				if err := validate(schemas, file, additional, item, path+"."+key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasType checks whether a decoded JSON value has the given JSON Schema type.
+func hasType(value any, tp string) bool {
+	switch tp {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		v, ok := value.(float64)
+		return ok && v == float64(int64(v))
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return false
+}
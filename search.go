@@ -0,0 +1,158 @@
+package isso
+
+// coverRecord records the coverage of a requirement by an action, for undoing it.
+type coverRecord struct {
+	Requirement int
+	Samples     int
+	Capacity    bool // Whether the samples were deducted from the capacity.
+}
+
+// coverage maintains the coverage of requirements by the actions of the search incrementally.
+//
+// Actions are pushed and popped in the order of the search. Each requirement is covered by the actions
+// it can use, in the order they were pushed. Capacity is deducted for own samples, and restored on pop.
+//
+// Coverage does not depend on the capacity remaining when an action is pushed:
+// actions never take more samples than the capacity remaining at their creation,
+// so the total of own samples at a site and time never exceeds its capacity.
+type coverage struct {
+	problem   *Problem
+	index     [][]int // Requirements that can use an action, by site, time and matrix. See indexOf.
+	numTimes  int
+	capacity  [][]int       // Remaining capacity, by site and time.
+	remaining []int         // Remaining samples, by requirement.
+	entries   [][]ActionDef // Coverage entries, by requirement.
+	visits    []int         // Number of pushed actions, by site and time.
+	subjects  []int         // Requirement index by subject ID, or -1 if not in the problem.
+	outside   []ActionDef   // Fixed own actions outside the times of their requirement.
+	undo      []coverRecord
+	marks     []int // Length of the undo stack before each push.
+	// Order-independent hash of the pushed actions and the remaining capacity.
+	// Capacity is hashed relative to the initial capacity.
+	hash uint64
+}
+
+// newCoverage creates the coverage state for a problem, without any actions.
+func newCoverage(p *Problem) *coverage {
+	numTimes := p.NumTimes()
+	for i := range p.requirements {
+		for _, t := range p.requirements[i].Times {
+			numTimes = max(numTimes, t+1)
+		}
+	}
+
+	c := coverage{
+		problem:   p,
+		index:     make([][]int, len(p.capacity)*numTimes*len(p.reusable)),
+		numTimes:  numTimes,
+		capacity:  p.Capacity(),
+		remaining: make([]int, len(p.requirements)),
+		entries:   make([][]ActionDef, len(p.requirements)),
+		visits:    make([]int, len(p.capacity)*numTimes),
+		subjects:  make([]int, len(p.subjectNames)),
+	}
+	for i := range c.subjects {
+		c.subjects[i] = -1
+	}
+	for r := range p.requirements {
+		req := &p.requirements[r]
+		c.subjects[req.Subject] = r
+		c.remaining[r] = req.Samples
+		for t := 0; t < numTimes; t++ {
+			if !req.Window.Has(t) {
+				continue
+			}
+			for m := range p.reusable {
+				if p.reusable[req.Matrix][m] {
+					idx := c.indexOf(req.Site, t, MatrixID(m))
+					c.index[idx] = append(c.index[idx], r)
+				}
+			}
+		}
+	}
+	return &c
+}
+
+// indexOf returns the index into the requirements index for an action's site, time and matrix.
+func (c *coverage) indexOf(st SiteID, t int, m MatrixID) int {
+	return (int(st)*c.numTimes+t)*len(c.problem.reusable) + int(m)
+}
+
+// Push adds an action, and updates coverage and capacity.
+// Samples of fixed actions are not deducted from the capacity, as they are accounted for already.
+// Fixed actions are pushed before the search and never popped.
+func (c *coverage) Push(a *ActionDef, fixed bool) {
+	if fixed {
+		// Not covering their own requirement, these are only part of the schedule through this list.
+		if r := c.subjects[a.Subject]; r >= 0 && !c.problem.requirements[r].Window.Has(a.Time) {
+			c.outside = append(c.outside, *a)
+		}
+	}
+	c.marks = append(c.marks, len(c.undo))
+	c.hash += actionHash(a)
+	c.visits[int(a.Site)*c.numTimes+a.Time]++
+	for _, r := range c.index[c.indexOf(a.Site, a.Time, a.Matrix)] {
+		samples := min(a.Samples, c.remaining[r])
+		if samples <= 0 {
+			continue
+		}
+		req := &c.problem.requirements[r]
+		own := req.Subject == a.Subject
+		reuse := NoSubject
+		if !own {
+			reuse = a.Subject
+		}
+		c.remaining[r] -= samples
+		if own && !fixed {
+			c.updateCapacity(a.Site, a.Time, -samples)
+		}
+		c.entries[r] = append(c.entries[r], ActionDef{
+			Subject:       req.Subject,
+			Matrix:        req.Matrix,
+			Site:          req.Site,
+			Samples:       samples,
+			Time:          a.Time,
+			TargetSamples: req.Samples,
+			Reuse:         reuse,
+		})
+		c.undo = append(c.undo, coverRecord{
+			Requirement: r,
+			Samples:     samples,
+			Capacity:    own && !fixed,
+		})
+	}
+}
+
+// Pop removes the last pushed action, and restores coverage and capacity.
+func (c *coverage) Pop(a *ActionDef) {
+	mark := c.marks[len(c.marks)-1]
+	c.marks = c.marks[:len(c.marks)-1]
+	for i := len(c.undo) - 1; i >= mark; i-- {
+		rec := &c.undo[i]
+		c.remaining[rec.Requirement] += rec.Samples
+		if rec.Capacity {
+			c.updateCapacity(a.Site, a.Time, rec.Samples)
+		}
+		c.entries[rec.Requirement] = c.entries[rec.Requirement][:len(c.entries[rec.Requirement])-1]
+	}
+	c.undo = c.undo[:mark]
+	c.hash -= actionHash(a)
+	c.visits[int(a.Site)*c.numTimes+a.Time]--
+}
+
+// updateCapacity changes the remaining capacity of a site at a time, and updates the hash.
+func (c *coverage) updateCapacity(st SiteID, t int, delta int) {
+	capacity := &c.capacity[st][t]
+	c.hash -= capacityHash(st, t, *capacity)
+	*capacity += delta
+	c.hash += capacityHash(st, t, *capacity)
+}
+
+// Actions appends the coverage entries of all requirements to the given slice, in the order of requirements,
+// followed by fixed own actions outside the times of their requirement.
+func (c *coverage) Actions(actions []ActionDef) []ActionDef {
+	for _, e := range c.entries {
+		actions = append(actions, e...)
+	}
+	return append(actions, c.outside...)
+}
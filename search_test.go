@@ -0,0 +1,201 @@
+package isso_test
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+// generateProblem generates a random problem with the given number of subjects, time steps and sites.
+func generateProblem(seed int64, numSubjects, numTimes, numSites int) isso.ProblemDef {
+	rng := rand.New(rand.NewSource(seed))
+
+	matrices := []isso.Matrix{
+		{Name: "fruits & shoots", CanReuse: []string{}},
+		{Name: "fruits", CanReuse: []string{"fruits & shoots"}},
+		{Name: "shoots", CanReuse: []string{"fruits & shoots"}},
+	}
+
+	sites := make([]isso.Site, numSites)
+	for i := range sites {
+		capacity := make([]int, numTimes)
+		for t := range capacity {
+			capacity[t] = 50 * rng.Intn(7)
+		}
+		sites[i] = isso.Site{Name: fmt.Sprintf("Site %d", i), Capacity: capacity}
+	}
+
+	requirements := make([]isso.Requirement, numSubjects)
+	for i := range requirements {
+		start := rng.Intn(numTimes - 1)
+		length := 2 + rng.Intn(4)
+		times := []int{}
+		for t := start; t < start+length && t < numTimes; t++ {
+			times = append(times, t)
+		}
+		requirements[i] = isso.Requirement{
+			Subject: fmt.Sprintf("Pest %d", i),
+			Site:    sites[rng.Intn(numSites)].Name,
+			Matrix:  matrices[rng.Intn(len(matrices))].Name,
+			Samples: 50 + 10*rng.Intn(30),
+			Times:   times,
+		}
+	}
+
+	return isso.ProblemDef{
+		Matrices:     matrices,
+		Sites:        sites,
+		Requirements: requirements,
+	}
+}
+
+func TestSolveGenerated(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		def := generateProblem(seed, 6, 10, 2)
+		p := isso.NewProblem(def)
+
+		s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{})
+		solutions, ok := s.Solve(&p)
+		if !ok {
+			continue
+		}
+
+		capacity := map[string][]int{}
+		for _, site := range def.Sites {
+			capacity[site.Name] = site.Capacity
+		}
+		for _, sol := range solutions {
+			samples := map[string]int{}
+			used := map[string][]int{}
+			for _, site := range def.Sites {
+				used[site.Name] = make([]int, len(site.Capacity))
+			}
+			for _, a := range sol.Actions {
+				samples[a.Subject] += a.Samples
+				if a.Reuse == "" {
+					used[a.Site][a.Time] += a.Samples
+				}
+			}
+			for _, r := range def.Requirements {
+				assert.Equal(t, r.Samples, samples[r.Subject], "seed %d, subject %s", seed, r.Subject)
+			}
+			for site, u := range used {
+				for i := range u {
+					assert.LessOrEqual(t, u[i], capacity[site][i], "seed %d, site %s, time %d", seed, site, i)
+				}
+			}
+		}
+	}
+}
+
+// generatedResult is the result of solving a generated problem.
+type generatedResult struct {
+	count   int                              // Number of solutions.
+	fitness []fitness.TripsAndSamplesFitness // Fitness of the best solution, or the Pareto front.
+}
+
+// TestSolveGeneratedEquivalence checks that the search finds the same results as the solver
+// before the rewrite of the search core. Seeds without entry have no solution.
+//
+// Results were recorded with the pre-rewrite solver. Counts are of distinct schedules,
+// as equivalent solutions are reported only once (see [isso.Solution.Canonical]).
+func TestSolveGeneratedEquivalence(t *testing.T) {
+	tests := []struct {
+		name                            string
+		numSubjects, numTimes, numSites int
+		pareto                          bool
+		results                         map[int64]generatedResult
+	}{
+		{"trips", 6, 10, 1, false, map[int64]generatedResult{
+			2:  {4, []fitness.TripsAndSamplesFitness{{Trips: 3, Samples: 520}}},
+			4:  {2, []fitness.TripsAndSamplesFitness{{Trips: 3, Samples: 350}}},
+			7:  {2, []fitness.TripsAndSamplesFitness{{Trips: 4, Samples: 470}}},
+			9:  {8, []fitness.TripsAndSamplesFitness{{Trips: 4, Samples: 410}}},
+			10: {1, []fitness.TripsAndSamplesFitness{{Trips: 3, Samples: 700}}},
+			13: {15, []fitness.TripsAndSamplesFitness{{Trips: 5, Samples: 760}}},
+			16: {4, []fitness.TripsAndSamplesFitness{{Trips: 3, Samples: 520}}},
+			17: {4, []fitness.TripsAndSamplesFitness{{Trips: 4, Samples: 540}}},
+			18: {8, []fitness.TripsAndSamplesFitness{{Trips: 4, Samples: 830}}},
+		}},
+		{"pareto", 6, 10, 1, true, map[int64]generatedResult{
+			2:  {1, []fitness.TripsAndSamplesFitness{{Trips: 3, Samples: 520}}},
+			4:  {1, []fitness.TripsAndSamplesFitness{{Trips: 3, Samples: 350}}},
+			7:  {1, []fitness.TripsAndSamplesFitness{{Trips: 4, Samples: 470}}},
+			9:  {2, []fitness.TripsAndSamplesFitness{{Trips: 4, Samples: 410}, {Trips: 5, Samples: 360}}},
+			10: {2, []fitness.TripsAndSamplesFitness{{Trips: 4, Samples: 550}, {Trips: 3, Samples: 700}}},
+			13: {1, []fitness.TripsAndSamplesFitness{{Trips: 5, Samples: 760}}},
+			16: {1, []fitness.TripsAndSamplesFitness{{Trips: 3, Samples: 520}}},
+			17: {1, []fitness.TripsAndSamplesFitness{{Trips: 4, Samples: 540}}},
+			18: {1, []fitness.TripsAndSamplesFitness{{Trips: 4, Samples: 830}}},
+		}},
+		{"sites", 8, 10, 3, false, map[int64]generatedResult{
+			2:  {12, []fitness.TripsAndSamplesFitness{{Trips: 6, Samples: 1180}}},
+			3:  {2, []fitness.TripsAndSamplesFitness{{Trips: 6, Samples: 1000}}},
+			5:  {16, []fitness.TripsAndSamplesFitness{{Trips: 6, Samples: 1160}}},
+			12: {54, []fitness.TripsAndSamplesFitness{{Trips: 9, Samples: 1300}}},
+			13: {24, []fitness.TripsAndSamplesFitness{{Trips: 7, Samples: 1000}}},
+			14: {32, []fitness.TripsAndSamplesFitness{{Trips: 7, Samples: 820}}},
+			15: {12, []fitness.TripsAndSamplesFitness{{Trips: 6, Samples: 730}}},
+		}},
+	}
+
+	for _, tt := range tests {
+		for seed := int64(0); seed < 20; seed++ {
+			p := isso.NewProblem(generateProblem(seed, tt.numSubjects, tt.numTimes, tt.numSites))
+
+			var comp isso.Comparator[fitness.TripsAndSamplesFitness] = &fitness.TripsThenSamples{}
+			if tt.pareto {
+				comp = &fitness.TripsSamplesPareto{}
+			}
+			s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp)
+			solutions, ok := s.Solve(&p)
+
+			expected, feasible := tt.results[seed]
+			assert.Equal(t, feasible, ok, "%s, seed %d", tt.name, seed)
+			if !feasible {
+				continue
+			}
+			assert.Equal(t, expected.count, len(solutions), "%s, seed %d", tt.name, seed)
+
+			fit := []fitness.TripsAndSamplesFitness{}
+			for _, sol := range solutions {
+				if !slices.Contains(fit, sol.Fitness) {
+					fit = append(fit, sol.Fitness)
+				}
+			}
+			assert.ElementsMatch(t, expected.fitness, fit, "%s, seed %d", tt.name, seed)
+		}
+	}
+}
+
+func benchmarkSolve(b *testing.B, numSubjects, numTimes, numSites int, pareto bool) {
+	problems := make([]isso.Problem, 10)
+	for i := range problems {
+		problems[i] = isso.NewProblem(generateProblem(int64(i), numSubjects, numTimes, numSites))
+	}
+
+	var comp isso.Comparator[fitness.TripsAndSamplesFitness] = &fitness.TripsThenSamples{}
+	if pareto {
+		comp = &fitness.TripsSamplesPareto{}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range problems {
+			s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp)
+			s.Solve(&problems[j])
+		}
+	}
+}
+
+func BenchmarkSolve(b *testing.B) {
+	b.Run("small", func(b *testing.B) { benchmarkSolve(b, 6, 10, 1, false) })
+	b.Run("medium", func(b *testing.B) { benchmarkSolve(b, 8, 12, 2, false) })
+	b.Run("sites", func(b *testing.B) { benchmarkSolve(b, 8, 10, 3, false) })
+	b.Run("pareto", func(b *testing.B) { benchmarkSolve(b, 6, 10, 1, true) })
+}
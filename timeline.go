@@ -0,0 +1,291 @@
+package isso
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateFormat is the format of dates in timelines.
+const DateFormat = "2006-01-02"
+
+// Timeline maps time step indices to calendar dates or labels.
+//
+// Either Start and Step, or Labels should be given.
+type Timeline struct {
+	Start  string   // Date of the first time step, like "2024-05-01".
+	Step   string   // Length of a time step. One of [day week].
+	Labels []string // Explicit labels per time step.
+}
+
+// validate checks the timeline for errors.
+func (t *Timeline) validate() error {
+	if len(t.Labels) > 0 {
+		if t.Start != "" || t.Step != "" {
+			return fmt.Errorf("timeline can't have labels as well as start and step")
+		}
+		return nil
+	}
+	if _, err := time.Parse(DateFormat, t.Start); err != nil {
+		return fmt.Errorf("invalid timeline start: %s", err.Error())
+	}
+	if _, err := t.stepDays(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// stepDays returns the length of a time step in days.
+func (t *Timeline) stepDays() (int, error) {
+	switch t.Step {
+	case "day":
+		return 1, nil
+	case "week":
+		return 7, nil
+	default:
+		return 0, fmt.Errorf("unknown timeline step '%s'; must be one of [day week]", t.Step)
+	}
+}
+
+// Label returns the label of a time step.
+// For a timeline with start and step, it is the date of the first day of the time step.
+// Without a timeline, it is the time step index.
+func (t *Timeline) Label(step int) string {
+	if t == nil {
+		return strconv.Itoa(step)
+	}
+	if len(t.Labels) > 0 {
+		if step < 0 || step >= len(t.Labels) {
+			return strconv.Itoa(step)
+		}
+		return t.Labels[step]
+	}
+	start, err := time.Parse(DateFormat, t.Start)
+	if err != nil {
+		return strconv.Itoa(step)
+	}
+	days, err := t.stepDays()
+	if err != nil {
+		return strconv.Itoa(step)
+	}
+	return start.AddDate(0, 0, step*days).Format(DateFormat)
+}
+
+// Index returns the index of the time step given by a label, a date or an index.
+// Indices must not be negative, and for a timeline with labels, they must be in the range of the labels.
+func (t *Timeline) Index(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if idx, err := strconv.Atoi(s); err == nil {
+		return idx, t.checkIndex(idx)
+	}
+	if t == nil {
+		return 0, fmt.Errorf("invalid time '%s'; dates and labels require a timeline", s)
+	}
+	if len(t.Labels) > 0 {
+		for i, l := range t.Labels {
+			if l == s {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown time label '%s'", s)
+	}
+
+	date, err := time.Parse(DateFormat, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s'; must be an index or a date", s)
+	}
+	start, err := time.Parse(DateFormat, t.Start)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeline start: %s", err.Error())
+	}
+	days, err := t.stepDays()
+	if err != nil {
+		return 0, err
+	}
+	if date.Before(start) {
+		return 0, fmt.Errorf("date %s is before timeline start %s", s, t.Start)
+	}
+	return int(date.Sub(start).Hours()/24) / days, nil
+}
+
+// checkIndex checks whether a time step index is in the range of the timeline.
+func (t *Timeline) checkIndex(idx int) error {
+	if idx < 0 {
+		return fmt.Errorf("negative time '%d'", idx)
+	}
+	if t != nil && len(t.Labels) > 0 && idx >= len(t.Labels) {
+		return fmt.Errorf("time %d is beyond the last time step %s", idx, t.Labels[len(t.Labels)-1])
+	}
+	return nil
+}
+
+// Parse parses a time specification to a list of time step indices.
+// A specification can be an index, a label or a date,
+// or a range of these like "2024-05-01..2024-06-15". Ranges are inclusive.
+func (t *Timeline) Parse(spec string) ([]int, error) {
+	from, to, isRange := strings.Cut(spec, "..")
+	first, err := t.Index(from)
+	if err != nil {
+		return nil, err
+	}
+	if !isRange {
+		return []int{first}, nil
+	}
+	last, err := t.Index(to)
+	if err != nil {
+		return nil, err
+	}
+	if last < first {
+		return nil, fmt.Errorf("invalid time range '%s'; end is before start", spec)
+	}
+	times := make([]int, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		times = append(times, i)
+	}
+	return times, nil
+}
+
+// Format formats a list of time steps as labels, with consecutive steps combined to ranges like in [Timeline.Parse].
+// Without a timeline, time step indices are used.
+func (t *Timeline) Format(times []int) string {
+	parts := []string{}
+	for i := 0; i < len(times); i++ {
+		start := i
+		for i+1 < len(times) && times[i+1] == times[i]+1 {
+			i++
+		}
+		if i == start {
+			parts = append(parts, t.Label(times[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s..%s", t.Label(times[start]), t.Label(times[i])))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseTime parses a JSON time entry, which is an index or a string accepted by [Timeline.Parse].
+func (t *Timeline) parseTime(entry json.RawMessage) ([]int, error) {
+	var spec string
+	if err := json.Unmarshal(entry, &spec); err == nil {
+		return t.Parse(spec)
+	}
+	var idx int
+	if err := json.Unmarshal(entry, &idx); err != nil {
+		return nil, fmt.Errorf("invalid time entry %s; must be an integer or a string", string(entry))
+	}
+	if err := t.checkIndex(idx); err != nil {
+		return nil, err
+	}
+	return []int{idx}, nil
+}
+
+// parseTimes parses a list of JSON time entries and checks them against the problem's time steps.
+// See [Timeline.parseTime] and [ProblemDef.checkTimes].
+func (p *ProblemDef) parseTimes(entries []json.RawMessage) ([]int, *timeError) {
+	times := []int{}
+	for i, e := range entries {
+		tm, err := p.Timeline.parseTime(e)
+		if err == nil {
+			err = p.checkTimes(tm)
+		}
+		if err != nil {
+			return nil, &timeError{path: []any{i}, err: err}
+		}
+		times = append(times, tm...)
+	}
+	return times, nil
+}
+
+// timeError is an error in a time entry of a problem definition.
+// It keeps the path to the entry, for reporting the source position.
+type timeError struct {
+	path []any // Object keys and array indices, like "Requirements", 0, "Times", 2.
+	err  error
+}
+
+func (e *timeError) Error() string {
+	return e.err.Error()
+}
+
+// in adds context to the error, with the path of the list of time entries.
+func (e *timeError) in(context string, path ...any) *timeError {
+	return &timeError{
+		path: append(path, e.path...),
+		err:  fmt.Errorf("%s: %s", context, e.err.Error()),
+	}
+}
+
+// UnmarshalJSON decodes a problem definition from JSON.
+// Requirement and constraint times can be given as indices, or as strings accepted by [Timeline.Parse].
+func (p *ProblemDef) UnmarshalJSON(data []byte) error {
+	type problemDef ProblemDef
+	type requirement struct {
+		Requirement
+		Times []json.RawMessage
+	}
+	type constraint struct {
+		ConstraintDef
+		Times []json.RawMessage
+	}
+	aux := struct {
+		*problemDef
+		Requirements []requirement
+		Constraints  []constraint
+	}{problemDef: (*problemDef)(p)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	p.Requirements = make([]Requirement, len(aux.Requirements))
+	for i, r := range aux.Requirements {
+		times, err := p.parseTimes(r.Times)
+		if err != nil {
+			return err.in(fmt.Sprintf("times of subject '%s'", r.Subject), "Requirements", i, "Times")
+		}
+		r.Requirement.Times = times
+		p.Requirements[i] = r.Requirement
+	}
+
+	p.Constraints = nil
+	for i, c := range aux.Constraints {
+		times, err := p.parseTimes(c.Times)
+		if err != nil {
+			return err.in(fmt.Sprintf("times of %s constraint %d", c.Type, i), "Constraints", i, "Times")
+		}
+		c.ConstraintDef.Times = times
+		p.Constraints = append(p.Constraints, c.ConstraintDef)
+	}
+	return nil
+}
+
+// NumTimes returns the number of time steps defined by the capacity, the sites and the timeline labels.
+// It is zero if none of these are given.
+func (p *ProblemDef) NumTimes() int {
+	numTimes := len(p.Capacity)
+	for _, s := range p.Sites {
+		numTimes = max(numTimes, len(s.Capacity))
+	}
+	if p.Timeline != nil {
+		numTimes = max(numTimes, len(p.Timeline.Labels))
+	}
+	return numTimes
+}
+
+// checkTimes checks whether times are in the range of the problem's time steps.
+// Without capacity or timeline labels, only negative times are rejected.
+func (p *ProblemDef) checkTimes(times []int) error {
+	numTimes := p.NumTimes()
+	for _, t := range times {
+		if t < 0 {
+			return fmt.Errorf("negative time '%d'", t)
+		}
+		if numTimes > 0 && t >= numTimes {
+			return fmt.Errorf("time %s is beyond the last time step %s",
+				p.Timeline.Label(t), p.Timeline.Label(numTimes-1))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package isso_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimelineDates(t *testing.T) {
+	tl := isso.Timeline{Start: "2024-05-01", Step: "week"}
+
+	assert.Equal(t, "2024-05-01", tl.Label(0))
+	assert.Equal(t, "2024-05-15", tl.Label(2))
+
+	idx, err := tl.Index("2024-05-14")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, idx)
+
+	idx, err = tl.Index("3")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, idx)
+
+	_, err = tl.Index("2024-04-30")
+	assert.NotNil(t, err)
+
+	times, err := tl.Parse("2024-05-01..2024-05-20")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2}, times)
+
+	_, err = tl.Parse("2024-05-20..2024-05-01")
+	assert.NotNil(t, err)
+
+	tl = isso.Timeline{Start: "2024-05-01", Step: "day"}
+	assert.Equal(t, "2024-05-03", tl.Label(2))
+}
+
+func TestTimelineLabels(t *testing.T) {
+	tl := isso.Timeline{Labels: []string{"KW18", "KW19", "KW20"}}
+
+	assert.Equal(t, "KW19", tl.Label(1))
+	assert.Equal(t, "5", tl.Label(5))
+
+	times, err := tl.Parse("KW19..KW20")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, times)
+
+	_, err = tl.Parse("KW21")
+	assert.NotNil(t, err)
+
+	_, err = tl.Parse("1..3")
+	assert.Equal(t, "time 3 is beyond the last time step KW20", err.Error())
+
+	assert.Equal(t, "KW18..KW20", tl.Format([]int{0, 1, 2}))
+	assert.Equal(t, "KW18, KW20", tl.Format([]int{0, 2}))
+}
+
+func TestTimelineNil(t *testing.T) {
+	var tl *isso.Timeline
+
+	assert.Equal(t, "3", tl.Label(3))
+
+	times, err := tl.Parse("2..4")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 3, 4}, times)
+
+	_, err = tl.Parse("2024-05-01")
+	assert.NotNil(t, err)
+
+	_, err = tl.Parse("-1")
+	assert.Equal(t, "negative time '-1'", err.Error())
+
+	assert.Equal(t, "1..3, 5", tl.Format([]int{1, 2, 3, 5}))
+	assert.Equal(t, "", tl.Format(nil))
+}
+
+func TestProblemDefUnmarshal(t *testing.T) {
+	js := `{
+		"Matrices": [{"Name": "fruits", "CanReuse": []}],
+		"Capacity": [100, 100, 100, 100],
+		"Timeline": {"Start": "2024-05-01", "Step": "week"},
+		"Requirements": [
+			{"Subject": "Pest 1", "Matrix": "fruits", "Samples": 100, "Times": [0, "2024-05-08..2024-05-15", "3"]}
+		]
+	}`
+
+	problem := isso.ProblemDef{}
+	err := json.Unmarshal([]byte(js), &problem)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3}, problem.Requirements[0].Times)
+	assert.Equal(t, "fruits", problem.Requirements[0].Matrix)
+	assert.Equal(t, 4, len(problem.Capacity))
+
+	js = `{"Requirements": [{"Subject": "Pest 1", "Times": ["2024-05-08"]}]}`
+	problem = isso.ProblemDef{}
+	err = json.Unmarshal([]byte(js), &problem)
+	assert.NotNil(t, err)
+
+	js = `{"Requirements": [{"Subject": "Pest 1", "Times": [true]}]}`
+	err = json.Unmarshal([]byte(js), &problem)
+	assert.NotNil(t, err)
+
+	js = `{"Capacity": [100, 100], "Timeline": {"Start": "2024-05-01", "Step": "week"},
+		"Requirements": [{"Subject": "Pest 1", "Times": ["2024-05-01..2024-05-15"]}]}`
+	err = json.Unmarshal([]byte(js), &problem)
+	assert.Equal(t, "times of subject 'Pest 1': time 2024-05-15 is beyond the last time step 2024-05-08", err.Error())
+
+	js = `{"Capacity": [100, 100], "Requirements": [{"Subject": "Pest 1", "Times": [62]}]}`
+	problem = isso.ProblemDef{}
+	err = json.Unmarshal([]byte(js), &problem)
+	assert.Equal(t, "times of subject 'Pest 1': time 62 is beyond the last time step 1", err.Error())
+
+	js = `{"Requirements": [{"Subject": "Pest 1", "Times": [-1]}]}`
+	err = json.Unmarshal([]byte(js), &problem)
+	assert.Equal(t, "times of subject 'Pest 1': negative time '-1'", err.Error())
+}
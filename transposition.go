@@ -0,0 +1,64 @@
+package isso
+
+// tableEntryBytes is the memory size of a transposition table entry.
+const tableEntryBytes = 8
+
+// transpositionTable is a bounded, direct-mapped table of search state hashes.
+// On collisions of table slots, the newer state replaces the older one.
+type transpositionTable struct {
+	entries []uint64
+	mask    uint64
+}
+
+// newTranspositionTable creates a table with the largest power of two number of entries that fits into the given bytes.
+// Returns nil if not even a single entry fits.
+func newTranspositionTable(bytes int) *transpositionTable {
+	size := 1
+	for size*2*tableEntryBytes <= bytes {
+		size *= 2
+	}
+	if size*tableEntryBytes > bytes {
+		return nil
+	}
+	return &transpositionTable{
+		entries: make([]uint64, size),
+		mask:    uint64(size - 1),
+	}
+}
+
+// Visit checks whether a state was visited before, and stores it otherwise.
+func (t *transpositionTable) Visit(hash uint64) bool {
+	if hash == 0 {
+		hash = 1 // Zero marks empty entries.
+	}
+	slot := &t.entries[hash&t.mask]
+	if *slot == hash {
+		return true
+	}
+	*slot = hash
+	return false
+}
+
+// mix is the finalizer of the SplitMix64 random number generator, used for hashing.
+func mix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// actionHash hashes an action for the additive, order-independent hash of a multiset of actions.
+func actionHash(a *ActionDef) uint64 {
+	h := mix(uint64(a.Subject))
+	h = mix(h ^ uint64(a.Matrix))
+	h = mix(h ^ uint64(a.Site))
+	h = mix(h ^ uint64(a.Time))
+	return mix(h ^ uint64(a.Samples))
+}
+
+// capacityHash hashes the remaining capacity of a site at a time.
+func capacityHash(st SiteID, t int, capacity int) uint64 {
+	h := mix(0x5bd1e995 ^ uint64(st))
+	h = mix(h ^ uint64(t))
+	return mix(h ^ uint64(capacity))
+}
@@ -0,0 +1,77 @@
+package isso_test
+
+import (
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranspositionTable(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		p := isso.NewProblem(generateProblem(seed, 7, 10, 2))
+
+		for _, pareto := range []bool{false, true} {
+			var comp isso.Comparator[fitness.TripsAndSamplesFitness] = &fitness.TripsThenSamples{}
+			if pareto {
+				comp = &fitness.TripsSamplesPareto{}
+			}
+
+			s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp)
+			solutions, ok := s.Solve(&p)
+			stats := s.Stats()
+			assert.Equal(t, 0, stats.TableSize)
+			assert.Equal(t, 0, stats.Probes)
+
+			st := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, comp, isso.WithTranspositionTable(1<<16))
+			solutionsTT, okTT := st.Solve(&p)
+			statsTT := st.Stats()
+			assert.Equal(t, ok, okTT)
+			assert.Equal(t, 8192, statsTT.TableSize)
+			assert.LessOrEqual(t, statsTT.Nodes, stats.Nodes)
+			assert.LessOrEqual(t, statsTT.Hits, statsTT.Probes)
+
+			fit := map[fitness.TripsAndSamplesFitness]bool{}
+			for _, sol := range solutions {
+				fit[sol.Fitness] = true
+			}
+			fitTT := map[fitness.TripsAndSamplesFitness]bool{}
+			for _, sol := range solutionsTT {
+				fitTT[sol.Fitness] = true
+			}
+			assert.Equal(t, fit, fitTT, "seed %d, pareto %v", seed, pareto)
+			assert.LessOrEqual(t, len(solutionsTT), len(solutions))
+		}
+	}
+}
+
+func TestTranspositionTableHits(t *testing.T) {
+	p := isso.NewProblem(isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}},
+		Capacity: []int{100, 100, 100, 100},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Matrix: "fruits", Samples: 200, Times: []int{0, 1, 2, 3}},
+		},
+	})
+
+	s := isso.NewSolver[fitness.TripsAndSamplesFitness](
+		&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{}, isso.WithTranspositionTable(1024),
+	)
+	solutions, ok := s.Solve(&p)
+	assert.True(t, ok)
+	assert.Equal(t, 6, len(solutions))
+
+	stats := s.Stats()
+	assert.Equal(t, 128, stats.TableSize)
+	assert.Greater(t, stats.Hits, 0)
+	assert.Greater(t, stats.HitRate(), 0.0)
+
+	s = isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{})
+	solutions, ok = s.Solve(&p)
+	assert.True(t, ok)
+	// Solutions with the same actions in a different order are deduplicated.
+	assert.Equal(t, 6, len(solutions))
+	stats = s.Stats()
+	assert.Equal(t, 0.0, stats.HitRate())
+}
@@ -0,0 +1,125 @@
+package isso
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/mlange-42/isso/route"
+)
+
+// Travel defines travel durations between a depot and the sites, for route-aware trip costs.
+type Travel struct {
+	Depot string // Name of the depot.
+	// Travel durations between depot and sites.
+	// Index 0 is the depot, followed by the sites in the order of their definition.
+	Durations [][]float64
+	// Maximum duration of the tour of a single trip. Zero for no limit.
+	MaxDuration float64
+}
+
+// Tour of a trip, for problems with travel durations.
+type Tour struct {
+	Time     int
+	Label    string   // Label or date of the time step, if the problem has a timeline.
+	Stops    []string // Depot and sites in the order of visit. Starts and ends at the depot.
+	Duration float64
+}
+
+// validate checks the travel definition for errors.
+func (t *Travel) validate(numSites int) error {
+	if len(t.Durations) != numSites+1 {
+		return fmt.Errorf("travel durations require %d rows for depot and sites, got %d", numSites+1, len(t.Durations))
+	}
+	for i, row := range t.Durations {
+		if len(row) != numSites+1 {
+			return fmt.Errorf("travel durations require %d columns for depot and sites, got %d in row %d", numSites+1, len(row), i)
+		}
+	}
+	return nil
+}
+
+// Travel returns the travel definition of the problem, or nil if there is none.
+func (p *Problem) Travel() *Travel {
+	return p.travel
+}
+
+// visitedSites returns the route nodes of the sites visited at the given time.
+func (p *Problem) visitedSites(actions []ActionDef, time int) []int {
+	nodes := []int{}
+	for i := range actions {
+		a := &actions[i]
+		if a.Time != time || a.Reuse >= 0 {
+			continue
+		}
+		if node := int(a.Site) + 1; !slices.Contains(nodes, node) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// tourFeasible checks whether visiting a site at a time may keep the tour within the maximum duration.
+// Tours of more than [route.ExactLimit] sites are not checked, as their heuristic duration
+// may be overestimated, and may even be shorter after adding further sites. See [Problem.toursFeasible].
+func (p *Problem) tourFeasible(actions []ActionDef, st SiteID, time int) bool {
+	if p.travel == nil || p.travel.MaxDuration <= 0 {
+		return true
+	}
+	nodes := p.visitedSites(actions, time)
+	node := int(st) + 1
+	if slices.Contains(nodes, node) || len(nodes) >= route.ExactLimit {
+		return true
+	}
+	_, duration := route.Tour(p.travel.Durations, append(nodes, node))
+	return duration <= p.travel.MaxDuration
+}
+
+// toursFeasible checks whether the tours of all trips of a complete schedule are within the maximum duration.
+func (p *Problem) toursFeasible(actions []ActionDef) bool {
+	if p.travel == nil || p.travel.MaxDuration <= 0 {
+		return true
+	}
+	for _, tour := range p.tours(actions) {
+		if tour.Duration > p.travel.MaxDuration {
+			return false
+		}
+	}
+	return true
+}
+
+// tours calculates the tours of all trips, for problems with travel durations.
+func (p *Problem) tours(actions []ActionDef) []Tour {
+	if p.travel == nil {
+		return nil
+	}
+	times := []int{}
+	for i := range actions {
+		if a := &actions[i]; a.Reuse < 0 && !slices.Contains(times, a.Time) {
+			times = append(times, a.Time)
+		}
+	}
+	slices.Sort(times)
+
+	tours := make([]Tour, len(times))
+	for i, t := range times {
+		order, duration := route.Tour(p.travel.Durations, p.visitedSites(actions, t))
+		stops := make([]string, 0, len(order)+2)
+		stops = append(stops, p.travel.Depot)
+		for _, node := range order {
+			stops = append(stops, p.siteNames[SiteID(node-1)])
+		}
+		stops = append(stops, p.travel.Depot)
+
+		var label string
+		if p.timeline != nil {
+			label = p.timeline.Label(t)
+		}
+		tours[i] = Tour{
+			Time:     t,
+			Label:    label,
+			Stops:    stops,
+			Duration: duration,
+		}
+	}
+	return tours
+}
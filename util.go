@@ -3,6 +3,7 @@ package isso
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -13,41 +14,130 @@ func min(a, b int) int {
 	return b
 }
 
+// timeLabel returns the label of the action's time step, or the index if there is no label.
+func (a *Action) timeLabel() string {
+	if a.Label != "" {
+		return a.Label
+	}
+	return strconv.Itoa(a.Time)
+}
+
+// timeWidth returns the maximum width of the time labels of the solution's actions.
+func (s *Solution[F]) timeWidth(minWidth int) int {
+	width := minWidth
+	for i := range s.Actions {
+		width = max(width, len(s.Actions[i].timeLabel()))
+	}
+	return width
+}
+
+// hasSites checks whether any of the solution's actions has a site.
+func (s *Solution[F]) hasSites() bool {
+	for i := range s.Actions {
+		if s.Actions[i].Site != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLabs checks whether the solution's actions are assigned to labs.
+func (s *Solution[F]) hasLabs() bool {
+	return len(s.Submissions) > 0
+}
+
+// bySite returns the solution's actions, grouped by site.
+// Sites are sorted by name, and actions retain their order within sites.
+func (s *Solution[F]) bySite() [][]Action {
+	sites := []string{}
+	groups := map[string][]Action{}
+	for _, a := range s.Actions {
+		if _, ok := groups[a.Site]; !ok {
+			sites = append(sites, a.Site)
+		}
+		groups[a.Site] = append(groups[a.Site], a)
+	}
+	sort.Strings(sites)
+
+	result := make([][]Action, len(sites))
+	for i, site := range sites {
+		result[i] = groups[site]
+	}
+	return result
+}
+
 // ToTable formats the solution as a table for printing.
+// For problems with multiple sites, actions are grouped by site.
 func (s *Solution[F]) ToTable() string {
 	b := strings.Builder{}
+	tw := s.timeWidth(6)
+	sites := s.hasSites()
+	labs := s.hasLabs()
 
+	if sites {
+		b.WriteString(fmt.Sprintf("%12s ", "Site"))
+	}
 	b.WriteString(
-		fmt.Sprintf("%10s %18s %6s %10s %10s %10s\n", "Subject", "Matrix", "Time", "Samples", "Reuse", "Target"),
+		fmt.Sprintf("%10s %18s %*s %10s %10s %10s", "Subject", "Matrix", tw, "Time", "Samples", "Reuse", "Target"),
 	)
+	if labs {
+		b.WriteString(fmt.Sprintf(" %12s", "Lab"))
+	}
+	b.WriteString("\n")
 
-	for i, a := range s.Actions {
-		b.WriteString(
-			fmt.Sprintf("%10s %18s %6d %10d %10s %10d", a.Subject, a.Matrix, a.Time, a.Samples, a.Reuse, a.TargetSamples),
-		)
-		if i < len(s.Actions)-1 {
-			b.WriteString("\n")
+	lines := []string{}
+	for _, group := range s.bySite() {
+		for _, a := range group {
+			line := fmt.Sprintf("%10s %18s %*s %10d %10s %10d", a.Subject, a.Matrix, tw, a.timeLabel(), a.Samples, a.Reuse, a.TargetSamples)
+			if sites {
+				line = fmt.Sprintf("%12s %s", a.Site, line)
+			}
+			if labs {
+				line = fmt.Sprintf("%s %12s", line, a.Lab)
+			}
+			lines = append(lines, line)
 		}
 	}
+	b.WriteString(strings.Join(lines, "\n"))
+
 	return b.String()
 }
 
 // ToCSV formats the solution as a CSV table.
+// For problems with multiple sites, actions are grouped by site.
 func (s *Solution[F]) ToCSV(index int, sep string) string {
 	b := strings.Builder{}
+	sites := s.hasSites()
+	labs := s.hasLabs()
 
 	if index <= 0 {
 		if index >= 0 {
 			b.WriteString(fmt.Sprintf("%s%s", "Solution", sep))
 		}
-		b.WriteString(fmt.Sprintf("%s%s%s%s%s%s%s%s%s%s%s\n", "Subject", sep, "Matrix", sep, "Time", sep, "Samples", sep, "Reuse", sep, "Target"))
+		if sites {
+			b.WriteString(fmt.Sprintf("%s%s", "Site", sep))
+		}
+		b.WriteString(fmt.Sprintf("%s%s%s%s%s%s%s%s%s%s%s", "Subject", sep, "Matrix", sep, "Time", sep, "Samples", sep, "Reuse", sep, "Target"))
+		if labs {
+			b.WriteString(fmt.Sprintf("%s%s", sep, "Lab"))
+		}
+		b.WriteString("\n")
 	}
 
-	for _, a := range s.Actions {
-		if index >= 0 {
-			b.WriteString(fmt.Sprintf("%d%s", index, sep))
+	for _, group := range s.bySite() {
+		for _, a := range group {
+			if index >= 0 {
+				b.WriteString(fmt.Sprintf("%d%s", index, sep))
+			}
+			if sites {
+				b.WriteString(fmt.Sprintf("%s%s", a.Site, sep))
+			}
+			b.WriteString(fmt.Sprintf("%s%s%s%s%s%s%d%s%s%s%d", a.Subject, sep, a.Matrix, sep, a.timeLabel(), sep, a.Samples, sep, a.Reuse, sep, a.TargetSamples))
+			if labs {
+				b.WriteString(fmt.Sprintf("%s%s", sep, a.Lab))
+			}
+			b.WriteString("\n")
 		}
-		b.WriteString(fmt.Sprintf("%s%s%s%s%d%s%d%s%s%s%d\n", a.Subject, sep, a.Matrix, sep, a.Time, sep, a.Samples, sep, a.Reuse, sep, a.TargetSamples))
 	}
 	return b.String()
 }
@@ -58,16 +148,33 @@ type timeEntry struct {
 }
 
 // ToList formats the solution as list for printing.
+// For problems with multiple sites, actions are grouped by site.
 func (s *Solution[F]) ToList() string {
-	b := strings.Builder{}
+	tw := s.timeWidth(2)
+	if !s.hasSites() {
+		return strings.Join(listActions(s.Actions, tw), "\n")
+	}
+
+	lines := []string{}
+	for _, group := range s.bySite() {
+		lines = append(lines, fmt.Sprintf("Site = %s", group[0].Site))
+		lines = append(lines, listActions(group, tw)...)
+	}
+	return strings.Join(lines, "\n")
+}
 
+// listActions formats actions as list lines, grouped by time and matrix.
+func listActions(actions []Action, tw int) []string {
 	times := []map[string]timeEntry{}
+	labels := []string{}
 
 	matrices := map[string]string{}
-	for _, a := range s.Actions {
+	for _, a := range actions {
 		for len(times) <= a.Time {
 			times = append(times, map[string]timeEntry{})
+			labels = append(labels, strconv.Itoa(len(labels)))
 		}
+		labels[a.Time] = a.timeLabel()
 
 		if a.Reuse == "" {
 			t := times[a.Time]
@@ -79,7 +186,7 @@ func (s *Solution[F]) ToList() string {
 		}
 	}
 
-	for _, a := range s.Actions {
+	for _, a := range actions {
 		if a.Reuse != "" {
 			t := times[a.Time]
 			matrix := matrices[a.Reuse]
@@ -87,6 +194,8 @@ func (s *Solution[F]) ToList() string {
 		}
 	}
 
+	indent := strings.Repeat(" ", tw+9)
+
 	lines := []string{}
 	for i, t := range times {
 		if len(t) == 0 {
@@ -95,9 +204,9 @@ func (s *Solution[F]) ToList() string {
 		first := true
 		for matrix, entry := range t {
 			if first {
-				lines = append(lines, fmt.Sprintf("Time = %2d: %4d x %-16s", i, entry.Samples, matrix))
+				lines = append(lines, fmt.Sprintf("Time = %*s: %4d x %-16s", tw, labels[i], entry.Samples, matrix))
 			} else {
-				lines = append(lines, fmt.Sprintf("           %4d x %-16s", entry.Samples, matrix))
+				lines = append(lines, fmt.Sprintf("%s%4d x %-16s", indent, entry.Samples, matrix))
 			}
 			keys := make([]string, 0, len(entry.Subjects))
 			for k := range entry.Subjects {
@@ -105,13 +214,33 @@ func (s *Solution[F]) ToList() string {
 			}
 			sort.Strings(keys)
 			for _, sub := range keys {
-				lines = append(lines, fmt.Sprintf("               %4d x %-16s", entry.Subjects[sub], sub))
+				lines = append(lines, fmt.Sprintf("%s    %4d x %-16s", indent, entry.Subjects[sub], sub))
 			}
 
 			first = false
 		}
 	}
-	b.WriteString(strings.Join(lines, "\n"))
+	return lines
+}
 
-	return b.String()
+// ToTours formats the tours of the solution's trips for printing.
+func (s *Solution[F]) ToTours() string {
+	tw := 2
+	for _, t := range s.Tours {
+		width := len(strconv.Itoa(t.Time))
+		if t.Label != "" {
+			width = len(t.Label)
+		}
+		tw = max(tw, width)
+	}
+
+	lines := make([]string, len(s.Tours))
+	for i, t := range s.Tours {
+		label := t.Label
+		if label == "" {
+			label = strconv.Itoa(t.Time)
+		}
+		lines[i] = fmt.Sprintf("Time = %*s: %8.2f  %s", tw, label, t.Duration, strings.Join(t.Stops, " -> "))
+	}
+	return strings.Join(lines, "\n")
 }
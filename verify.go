@@ -0,0 +1,230 @@
+package isso
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ViolationKind is the kind of a [Violation].
+type ViolationKind string
+
+const (
+	ViolationAction     ViolationKind = "action"     // Unknown subject, or matrix, site or time not matching the problem.
+	ViolationWindow     ViolationKind = "window"     // Samples outside of the times of the requirement.
+	ViolationCapacity   ViolationKind = "capacity"   // Own samples exceeding the capacity of a site at a time.
+	ViolationSamples    ViolationKind = "samples"    // Requirement not covered by enough samples.
+	ViolationReuse      ViolationKind = "reuse"      // Reuse of samples that are not reusable or not taken.
+	ViolationTour       ViolationKind = "tour"       // Tour exceeding the maximum tour duration.
+	ViolationConstraint ViolationKind = "constraint" // Constraint not satisfied.
+)
+
+// Violation of a problem by a schedule. See [Problem.Verify].
+type Violation struct {
+	Kind    ViolationKind
+	Subject string // Subject of the violating action or requirement. Empty if not specific to a subject.
+	Time    int    // Time of the violation, or -1 if not specific to a time.
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Kind, v.Message)
+}
+
+// Verify checks a schedule against the problem, and returns all violations.
+// The schedule is given by the actions of a solution, like [Solution.Actions],
+// so that solutions of any fitness type, hand-edited or imported schedules can be verified.
+//
+// Checks are:
+//   - Actions refer to subjects of the problem, with matching matrix and site, and a time in range.
+//     Empty matrix and site fields are taken from the requirement.
+//   - Own samples are within the times of the requirement, except for the problem's fixed actions.
+//   - Own samples don't exceed the capacity of the sites, in addition to fixed actions and before the current time.
+//   - Requirements are covered by their own and reused samples, within their times.
+//   - Reused samples are reusable for the matrix of the requirement, and taken by the source subject
+//     at the same site and time.
+//   - Tours don't exceed the maximum tour duration, for problems with travel durations.
+//   - The schedule is accepted by the problem's constraints and the given additional constraints.
+//
+// Returns an empty slice if the schedule is feasible.
+func (p *Problem) Verify(schedule []Action, constraints ...Constraint) []Violation {
+	violations := []Violation{}
+	add := func(kind ViolationKind, subject string, time int, format string, args ...any) {
+		violations = append(violations, Violation{
+			Kind:    kind,
+			Subject: subject,
+			Time:    time,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	index := map[SubjectID]int{}
+	for i := range p.requirements {
+		index[p.requirements[i].Subject] = i
+	}
+	// resolve translates an action to an action definition, and reports violations of its subject, matrix, site and time.
+	resolve := func(a *Action) (ActionDef, bool) {
+		sub, ok := p.subjectIDs[a.Subject]
+		r, inProblem := index[sub]
+		if !ok || !inProblem {
+			add(ViolationAction, a.Subject, a.Time, "unknown subject '%s'", a.Subject)
+			return ActionDef{}, false
+		}
+		req := &p.requirements[r]
+		if a.Matrix != "" && a.Matrix != p.matrixNames[req.Matrix] {
+			add(ViolationAction, a.Subject, a.Time, "action for subject '%s' has matrix '%s', but requirement has '%s'",
+				a.Subject, a.Matrix, p.matrixNames[req.Matrix])
+			return ActionDef{}, false
+		}
+		if a.Site != "" && a.Site != p.siteNames[req.Site] {
+			add(ViolationAction, a.Subject, a.Time, "action for subject '%s' has site '%s', but requirement has '%s'",
+				a.Subject, a.Site, p.siteNames[req.Site])
+			return ActionDef{}, false
+		}
+		if a.Time < 0 || a.Time >= len(p.capacity[req.Site]) {
+			add(ViolationAction, a.Subject, a.Time, "action for subject '%s' has time %d out of range", a.Subject, a.Time)
+			return ActionDef{}, false
+		}
+		return ActionDef{
+			Subject:       req.Subject,
+			Matrix:        req.Matrix,
+			Site:          req.Site,
+			Time:          a.Time,
+			Samples:       a.Samples,
+			TargetSamples: req.Samples,
+			Reuse:         NoSubject,
+		}, true
+	}
+
+	type key struct {
+		subject SubjectID
+		time    int
+	}
+	fixed := map[key]int{}
+	for i := range p.fixed {
+		fixed[key{p.fixed[i].Subject, p.fixed[i].Time}] += p.fixed[i].Samples
+	}
+
+	used := make([][]int, len(p.capacity))
+	for i := range used {
+		used[i] = make([]int, len(p.capacity[i]))
+	}
+	covered := make([]int, len(p.requirements))
+	taken := map[key]int{} // Own samples by subject and time.
+	own := []ActionDef{}
+
+	for i := range schedule {
+		a := &schedule[i]
+		if a.Reuse != "" {
+			continue
+		}
+		def, ok := resolve(a)
+		if !ok {
+			continue
+		}
+		req := &p.requirements[index[def.Subject]]
+		k := key{def.Subject, def.Time}
+		isFixed := min(fixed[k], def.Samples)
+		fixed[k] -= isFixed
+		planned := def.Samples - isFixed
+
+		if req.Window.Has(def.Time) {
+			covered[index[def.Subject]] += def.Samples
+		} else if planned > 0 {
+			add(ViolationWindow, a.Subject, a.Time, "subject '%s' has %d samples at time %d, outside of its times",
+				a.Subject, planned, a.Time)
+		}
+		used[def.Site][def.Time] += planned
+		taken[k] += def.Samples
+		own = append(own, def)
+	}
+
+	for st := range used {
+		for t, samples := range used[st] {
+			if samples > p.capacity[st][t] {
+				add(ViolationCapacity, "", t, "%d samples %s exceed the capacity of %d",
+					samples, p.where(SiteID(st), t), p.capacity[st][t])
+			}
+		}
+	}
+
+	for i := range schedule {
+		a := &schedule[i]
+		if a.Reuse == "" {
+			continue
+		}
+		def, ok := resolve(a)
+		if !ok {
+			continue
+		}
+		req := &p.requirements[index[def.Subject]]
+		source, ok := p.subjectIDs[a.Reuse]
+		r, inProblem := index[source]
+		if !ok || !inProblem {
+			add(ViolationReuse, a.Subject, a.Time, "subject '%s' reuses samples of unknown subject '%s'", a.Subject, a.Reuse)
+			continue
+		}
+		sourceReq := &p.requirements[r]
+		if !p.reusable[req.Matrix][sourceReq.Matrix] {
+			add(ViolationReuse, a.Subject, a.Time, "subject '%s' with matrix '%s' can't reuse samples of '%s' with matrix '%s'",
+				a.Subject, p.matrixNames[req.Matrix], a.Reuse, p.matrixNames[sourceReq.Matrix])
+			continue
+		}
+		if sourceReq.Site != req.Site {
+			add(ViolationReuse, a.Subject, a.Time, "subject '%s' at site '%s' can't reuse samples of '%s' at site '%s'",
+				a.Subject, p.siteNames[req.Site], a.Reuse, p.siteNames[sourceReq.Site])
+			continue
+		}
+		if taken[key{source, a.Time}] < a.Samples {
+			add(ViolationReuse, a.Subject, a.Time, "subject '%s' reuses %d samples of '%s' %s, but '%s' has only %d own samples there",
+				a.Subject, a.Samples, a.Reuse, p.where(req.Site, a.Time), a.Reuse, taken[key{source, a.Time}])
+			continue
+		}
+		if !req.Window.Has(a.Time) {
+			add(ViolationWindow, a.Subject, a.Time, "subject '%s' reuses %d samples at time %d, outside of its times",
+				a.Subject, a.Samples, a.Time)
+			continue
+		}
+		covered[index[def.Subject]] += a.Samples
+	}
+
+	for i := range p.requirements {
+		req := &p.requirements[i]
+		if covered[i] < req.Samples {
+			name := p.subjectNames[req.Subject]
+			add(ViolationSamples, name, -1, "subject '%s' is covered by %d of %d samples", name, covered[i], req.Samples)
+		}
+	}
+
+	if p.travel != nil && p.travel.MaxDuration > 0 {
+		for _, tour := range p.tours(own) {
+			if tour.Duration > p.travel.MaxDuration {
+				add(ViolationTour, "", tour.Time, "tour at time %d takes %.2f, exceeding the maximum of %.2f",
+					tour.Time, tour.Duration, p.travel.MaxDuration)
+			}
+		}
+	}
+
+	cov := newCoverage(p)
+	for i := range own {
+		cov.Push(&own[i], true)
+	}
+	state := SearchState{coverage: cov, actions: &actions{Actions: own}}
+	for _, c := range append(slices.Clone(p.constraints), constraints...) {
+		if _, ok := c.(maxTourDuration); ok {
+			continue // Reported per tour above.
+		}
+		if !c.Accept(&state) {
+			add(ViolationConstraint, "", -1, "constraint not satisfied: %s", p.describeConstraint(c))
+		}
+	}
+
+	return violations
+}
+
+// where formats a site and time for messages. The site is omitted for problems without sites.
+func (p *Problem) where(st SiteID, time int) string {
+	if name := p.siteNames[st]; name != "" {
+		return fmt.Sprintf("at site '%s' at time %d", name, time)
+	}
+	return fmt.Sprintf("at time %d", time)
+}
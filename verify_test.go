@@ -0,0 +1,221 @@
+package isso_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/mlange-42/isso"
+	"github.com/mlange-42/isso/fitness"
+	"github.com/stretchr/testify/assert"
+)
+
+// violationKinds returns the kinds of violations, in order.
+func violationKinds(violations []isso.Violation) []isso.ViolationKind {
+	kinds := make([]isso.ViolationKind, len(violations))
+	for i, v := range violations {
+		kinds[i] = v.Kind
+	}
+	return kinds
+}
+
+func TestVerifySolutions(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		def := generateProblem(seed, 6, 10, 2)
+		p := isso.NewProblem(def)
+		s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{},
+			isso.WithTopK(5))
+		solutions, ok := s.Solve(&p)
+		if !ok {
+			continue
+		}
+		for _, sol := range solutions {
+			assert.Empty(t, p.Verify(sol.Actions), fmt.Sprintf("seed %d", seed))
+		}
+
+		replanned := isso.NewProblem(isso.Replan(def, solutions[0], nil, 5))
+		solutions, ok = s.Solve(&replanned)
+		assert.True(t, ok, fmt.Sprintf("seed %d", seed))
+		for _, sol := range solutions {
+			assert.Empty(t, replanned.Verify(sol.Actions), fmt.Sprintf("seed %d", seed))
+		}
+	}
+}
+
+func TestVerifyFixedOutsideTimes(t *testing.T) {
+	p := isso.NewProblem(isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits", CanReuse: []string{}}},
+		Capacity: []int{100, 100, 100},
+		Requirements: []isso.Requirement{
+			{Subject: "A", Matrix: "fruits", Samples: 10, Times: []int{0}},
+			{Subject: "B", Matrix: "fruits", Samples: 5, Times: []int{1, 2}},
+		},
+		// Samples of A were collected later than required.
+		FixedActions: []isso.Action{{Subject: "A", Time: 1, Samples: 10}},
+	})
+	for _, opts := range [][]isso.Option{{}, {isso.WithDecomposition()}} {
+		s := isso.NewSolver[fitness.TripsAndSamplesFitness](&fitness.TripsAndSamplesEvaluator{}, &fitness.TripsThenSamples{}, opts...)
+		solutions, ok := s.Solve(&p)
+		assert.True(t, ok)
+		for _, sol := range solutions {
+			assert.Empty(t, p.Verify(sol.Actions))
+			assert.Contains(t, sol.Actions, isso.Action{Subject: "A", Matrix: "fruits", Time: 1, Samples: 10, TargetSamples: 10})
+			assert.NotPanics(t, func() { sol.ToList() })
+			assert.NotPanics(t, func() { sol.ToTable() })
+		}
+	}
+}
+
+func TestVerifyViolations(t *testing.T) {
+	p := isso.NewProblem(isso.ProblemDef{
+		Matrices: []isso.Matrix{
+			{Name: "fruits & shoots"},
+			{Name: "fruits", CanReuse: []string{"fruits & shoots"}},
+		},
+		Sites: []isso.Site{
+			{Name: "A", Capacity: []int{100, 100, 100}},
+			{Name: "B", Capacity: []int{100, 100, 100}},
+		},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Site: "A", Matrix: "fruits & shoots", Samples: 100, Times: []int{0, 1}},
+			{Subject: "Pest 2", Site: "A", Matrix: "fruits", Samples: 50, Times: []int{1, 2}},
+			{Subject: "Pest 3", Site: "B", Matrix: "fruits", Samples: 50, Times: []int{0, 1}},
+		},
+		FixedActions: []isso.Action{
+			{Subject: "Pest 3", Time: 2, Samples: 20},
+		},
+	})
+
+	valid := []isso.Action{
+		{Subject: "Pest 1", Matrix: "fruits & shoots", Site: "A", Time: 1, Samples: 100},
+		{Subject: "Pest 2", Matrix: "fruits", Site: "A", Time: 1, Samples: 50, Reuse: "Pest 1"},
+		{Subject: "Pest 3", Matrix: "fruits", Site: "B", Time: 0, Samples: 50},
+		{Subject: "Pest 3", Time: 2, Samples: 20},
+	}
+	assert.Empty(t, p.Verify(valid))
+
+	tests := []struct {
+		name   string
+		modify func(actions []isso.Action) []isso.Action
+		kinds  []isso.ViolationKind
+	}{
+		{"unknown subject", func(a []isso.Action) []isso.Action {
+			return append(a, isso.Action{Subject: "Pest 4", Time: 1, Samples: 10})
+		}, []isso.ViolationKind{isso.ViolationAction}},
+		{"wrong matrix", func(a []isso.Action) []isso.Action {
+			a[2].Matrix = "fruits & shoots"
+			return a
+		}, []isso.ViolationKind{isso.ViolationAction, isso.ViolationSamples}},
+		{"wrong site", func(a []isso.Action) []isso.Action {
+			a[2].Site = "A"
+			return a
+		}, []isso.ViolationKind{isso.ViolationAction, isso.ViolationSamples}},
+		{"time out of range", func(a []isso.Action) []isso.Action {
+			a[2].Time = 3
+			return a
+		}, []isso.ViolationKind{isso.ViolationAction, isso.ViolationSamples}},
+		{"window", func(a []isso.Action) []isso.Action {
+			return append(a, isso.Action{Subject: "Pest 1", Time: 2, Samples: 10})
+		}, []isso.ViolationKind{isso.ViolationWindow}},
+		{"fixed outside window", func(a []isso.Action) []isso.Action {
+			a[3].Samples = 30
+			return a
+		}, []isso.ViolationKind{isso.ViolationWindow}},
+		{"capacity", func(a []isso.Action) []isso.Action {
+			return append(a, isso.Action{Subject: "Pest 2", Time: 1, Samples: 10})
+		}, []isso.ViolationKind{isso.ViolationCapacity}},
+		{"reuse not reusable", func(a []isso.Action) []isso.Action {
+			return append(a, isso.Action{Subject: "Pest 1", Time: 0, Samples: 50, Reuse: "Pest 3"})
+		}, []isso.ViolationKind{isso.ViolationReuse}},
+		{"reuse not taken", func(a []isso.Action) []isso.Action {
+			a[1].Samples = 150
+			return a
+		}, []isso.ViolationKind{isso.ViolationReuse, isso.ViolationSamples}},
+		{"reuse other site", func(a []isso.Action) []isso.Action {
+			return append(a, isso.Action{Subject: "Pest 3", Time: 1, Samples: 50, Reuse: "Pest 1"})
+		}, []isso.ViolationKind{isso.ViolationReuse}},
+		{"reuse unknown subject", func(a []isso.Action) []isso.Action {
+			a[1].Reuse = "Pest 4"
+			return a
+		}, []isso.ViolationKind{isso.ViolationReuse, isso.ViolationSamples}},
+		{"samples", func(a []isso.Action) []isso.Action {
+			a[0].Samples = 60
+			a[1].Samples = 50
+			return a
+		}, []isso.ViolationKind{isso.ViolationSamples}},
+	}
+
+	for _, tt := range tests {
+		actions := tt.modify(slices.Clone(valid))
+		assert.Equal(t, tt.kinds, violationKinds(p.Verify(actions)), tt.name)
+	}
+
+	violations := p.Verify(nil)
+	assert.Equal(t, 3, len(violations))
+	assert.Equal(t, "samples: subject 'Pest 1' is covered by 0 of 100 samples", violations[0].String())
+	assert.Equal(t, -1, violations[0].Time)
+}
+
+func TestVerifyConstraints(t *testing.T) {
+	def := isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}},
+		Capacity: []int{100, 100, 100},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Matrix: "fruits", Samples: 50, Times: []int{0, 1}},
+			{Subject: "Pest 2", Matrix: "fruits", Samples: 50, Times: []int{1, 2}},
+		},
+		Constraints: []isso.ConstraintDef{
+			{Type: "forbidden-times", Times: []int{0, 1}},
+		},
+	}
+	p := isso.NewProblem(def)
+
+	actions := []isso.Action{
+		{Subject: "Pest 1", Time: 0, Samples: 50},
+		{Subject: "Pest 2", Time: 1, Samples: 50},
+	}
+	violations := p.Verify(actions)
+	assert.Equal(t, []isso.ViolationKind{isso.ViolationConstraint}, violationKinds(violations))
+	assert.Equal(t, "constraint: constraint not satisfied: not all of times 0..1", violations[0].String())
+
+	actions[0].Time = 1
+	assert.Empty(t, p.Verify(actions))
+
+	violations = p.Verify(actions, &isso.Precedence{Before: 1, After: 0})
+	assert.Equal(t, []isso.ViolationKind{isso.ViolationConstraint}, violationKinds(violations))
+	assert.Equal(t, "constraint: constraint not satisfied: 'Pest 2' before 'Pest 1'", violations[0].String())
+}
+
+func TestVerifyTours(t *testing.T) {
+	p := isso.NewProblem(isso.ProblemDef{
+		Matrices: []isso.Matrix{{Name: "fruits"}},
+		Sites: []isso.Site{
+			{Name: "A", Capacity: []int{100, 100}},
+			{Name: "B", Capacity: []int{100, 100}},
+		},
+		Requirements: []isso.Requirement{
+			{Subject: "Pest 1", Site: "A", Matrix: "fruits", Samples: 100, Times: []int{0}},
+			{Subject: "Pest 2", Site: "B", Matrix: "fruits", Samples: 100, Times: []int{0, 1}},
+		},
+		Travel: &isso.Travel{
+			Depot: "Depot",
+			Durations: [][]float64{
+				{0, 1, 2},
+				{1, 0, 2},
+				{2, 3, 0},
+			},
+			MaxDuration: 4.5,
+		},
+	})
+
+	actions := []isso.Action{
+		{Subject: "Pest 1", Time: 0, Samples: 100},
+		{Subject: "Pest 2", Time: 0, Samples: 100},
+	}
+	violations := p.Verify(actions)
+	assert.Equal(t, []isso.ViolationKind{isso.ViolationTour}, violationKinds(violations))
+	assert.Equal(t, 0, violations[0].Time)
+
+	actions[1].Time = 1
+	assert.Empty(t, p.Verify(actions))
+}